@@ -1,7 +1,7 @@
 package audience
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"time"
 )
@@ -21,22 +21,22 @@ type Account struct {
 
 //AccountsList - returns a list of accounts the current user is a representative of.
 func (c *Client) AccountsList() ([]*Account, error) {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodGet,
-	}, "accounts")
+	return c.AccountsListContext(context.Background())
+}
+
+//AccountsListContext - AccountsList with a context.
+func (c *Client) AccountsListContext(ctx context.Context) (accounts []*Account, err error) {
+	ctx, op, err := c.begin(ctx, "AccountsList", 0)
 	if err != nil {
 		return nil, err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Accounts []*Account `json:"accounts"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodGet, "accounts", nil, &response); err != nil {
 		return nil, err
 	}
-	if len(response.Errors) != 0 {
-		return nil, response.Error()
-	}
 	return response.Accounts, nil
 }
@@ -6,6 +6,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 	"time"
@@ -70,7 +71,42 @@ func TestClient_AccountsList(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			_, err := client.AccountsList()
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
+
+func TestClient_ForAccount(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	client, err := NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	Convey("act on behalf of account", t, func(c C) {
+		var queries []url.Values
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.Query())
+			_, _ = w.Write([]byte(`{"success":true,"segments":[],"grants":[]}`))
+		}))
+		defer ts.Close()
+		client.hc = ts.Client()
+		client.apiURL = ts.URL
+		account := client.ForAccount("client-login")
+		So(account.Account(), ShouldEqual, "client-login")
+		So(client.Account(), ShouldEqual, "")
+		_, err := account.SegmentsList(12)
+		So(err, ShouldBeNil)
+		_, err = account.GrantsList(1)
+		So(err, ShouldBeNil)
+		So(account.RemoveGrant(1, "guest"), ShouldBeNil)
+		_, err = client.SegmentsList()
+		So(err, ShouldBeNil)
+		So(len(queries), ShouldEqual, 4)
+		So(queries[0].Get("ulogin"), ShouldEqual, "client-login")
+		So(queries[0].Get("pixel"), ShouldEqual, "12")
+		So(queries[1].Get("ulogin"), ShouldEqual, "client-login")
+		So(queries[2].Get("ulogin"), ShouldEqual, "client-login")
+		So(queries[2].Get("user_login"), ShouldEqual, "guest")
+		So(queries[3].Get("ulogin"), ShouldEqual, "")
+	})
+}
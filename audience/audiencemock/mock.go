@@ -0,0 +1,535 @@
+//Package audiencemock - mock of audience.AudienceAPI for tests of the code using the client.
+//
+//	mock := &audiencemock.Client{
+//		SegmentsListFunc: func(ctx context.Context, pixel ...int) ([]audience.Segment, error) {
+//			return []audience.Segment{&audience.PixelSegment{BaseSegment: audience.BaseSegment{ID: 1}}}, nil
+//		},
+//	}
+//	service := NewService(mock) //the service depends on audience.AudienceAPI
+//
+//Methods without function set return ErrNotMocked. Every call is recorded in Calls.
+package audiencemock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+
+	"github.com/nikon72ru/yandex-audience-api/audience"
+)
+
+//ErrNotMocked - returned by the methods without function set
+var ErrNotMocked = errors.New("audiencemock: method isn't mocked")
+
+//Call - a recorded call of the mock method
+type Call struct {
+	//Method - name of the method (without Context suffix)
+	Method string
+	//Args - arguments of the call except context
+	Args []interface{}
+}
+
+//Client - mock of audience.AudienceAPI. Set functions of the methods used by the tested code.
+type Client struct {
+	SegmentsListFunc            func(ctx context.Context, pixel ...int) ([]audience.Segment, error)
+	CreateFileSegmentFunc       func(ctx context.Context, segment *audience.UploadingSegment, filename string) error
+	CreateCSVSegmentFunc        func(ctx context.Context, segment *audience.UploadingSegment, filename string) error
+	CreateReaderSegmentFunc     func(ctx context.Context, segment *audience.UploadingSegment, reader io.Reader, isCSV bool) error
+	ModifySegmentDataFunc       func(ctx context.Context, segment *audience.UploadingSegment, reader io.Reader, modificationType string) error
+	SaveUploadedSegmentFunc     func(ctx context.Context, segment *audience.UploadingSegment) error
+	SaveClientIDSegmentFunc     func(ctx context.Context, segment *audience.ClientIDSegment) error
+	RemoveSegmentFunc           func(ctx context.Context, id int64) error
+	CreatePixelSegmentFunc      func(ctx context.Context, segment *audience.PixelSegment) error
+	CreateLookalikeSegmentFunc  func(ctx context.Context, segment *audience.LookalikeSegment) error
+	CreateMetrikaSegmentFunc    func(ctx context.Context, segment *audience.MetrikaSegment) error
+	CreateAppMetrikaSegmentFunc func(ctx context.Context, segment *audience.AppMetricaSegment) error
+	CreateCircleGeoSegmentFunc  func(ctx context.Context, segment *audience.CircleGeoSegment) error
+	CreatePolygonGeoSegmentFunc func(ctx context.Context, segment *audience.PolygonGeoSegment) error
+	UpdateSegmentFunc           func(ctx context.Context, ID int64, segment interface{}) error
+	ReprocessSegmentFunc        func(ctx context.Context, segmentID int64) error
+	UpdateGeoPointsFunc         func(ctx context.Context, segment *audience.CircleGeoSegment, modificationType string) error
+	WaitForSegmentFunc          func(ctx context.Context, id int64, opts audience.WaitOptions) (audience.Segment, error)
+	PixelsListFunc              func(ctx context.Context) ([]*audience.Pixel, error)
+	CreatePixelFunc             func(ctx context.Context, pixel *audience.Pixel) error
+	RemovePixelFunc             func(ctx context.Context, pixelID int64) error
+	UpdatePixelFunc             func(ctx context.Context, pixel *audience.Pixel) error
+	UndeletePixelFunc           func(ctx context.Context, pixelID int64) error
+	GrantsListFunc              func(ctx context.Context, segmentID int64) ([]*audience.Grant, error)
+	CreateGrantFunc             func(ctx context.Context, segmentID int64, grant *audience.Grant) error
+	RemoveGrantFunc             func(ctx context.Context, segmentID int64, userLogin string) error
+	DelegatesListFunc           func(ctx context.Context) ([]*audience.Delegate, error)
+	CreateDelegateFunc          func(ctx context.Context, delegate *audience.Delegate) error
+	RemoveDelegateFunc          func(ctx context.Context, userLogin string) error
+	AccountsListFunc            func(ctx context.Context) ([]*audience.Account, error)
+	CallFunc                    func(ctx context.Context, method, path string, query url.Values, in, out interface{}) error
+	CloseFunc                   func() error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ audience.AudienceAPI = (*Client)(nil)
+
+//Calls - returns the recorded calls
+func (m *Client) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+//CallsOf - returns the recorded calls of the method
+func (m *Client) CallsOf(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (m *Client) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func notMocked(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotMocked, method)
+}
+
+//SegmentsList - calls SegmentsListFunc
+func (m *Client) SegmentsList(pixel ...int) ([]audience.Segment, error) {
+	return m.SegmentsListContext(context.Background(), pixel...)
+}
+
+//SegmentsListContext - calls SegmentsListFunc
+func (m *Client) SegmentsListContext(ctx context.Context, pixel ...int) ([]audience.Segment, error) {
+	m.record("SegmentsList", pixel)
+	if m.SegmentsListFunc == nil {
+		return nil, notMocked("SegmentsList")
+	}
+	return m.SegmentsListFunc(ctx, pixel...)
+}
+
+//CreateFileSegment - calls CreateFileSegmentFunc
+func (m *Client) CreateFileSegment(segment *audience.UploadingSegment, filename string) error {
+	return m.CreateFileSegmentContext(context.Background(), segment, filename)
+}
+
+//CreateFileSegmentContext - calls CreateFileSegmentFunc
+func (m *Client) CreateFileSegmentContext(ctx context.Context, segment *audience.UploadingSegment, filename string) error {
+	m.record("CreateFileSegment", segment, filename)
+	if m.CreateFileSegmentFunc == nil {
+		return notMocked("CreateFileSegment")
+	}
+	return m.CreateFileSegmentFunc(ctx, segment, filename)
+}
+
+//CreateCSVSegment - calls CreateCSVSegmentFunc
+func (m *Client) CreateCSVSegment(segment *audience.UploadingSegment, filename string) error {
+	return m.CreateCSVSegmentContext(context.Background(), segment, filename)
+}
+
+//CreateCSVSegmentContext - calls CreateCSVSegmentFunc
+func (m *Client) CreateCSVSegmentContext(ctx context.Context, segment *audience.UploadingSegment, filename string) error {
+	m.record("CreateCSVSegment", segment, filename)
+	if m.CreateCSVSegmentFunc == nil {
+		return notMocked("CreateCSVSegment")
+	}
+	return m.CreateCSVSegmentFunc(ctx, segment, filename)
+}
+
+//CreateReaderSegment - calls CreateReaderSegmentFunc
+func (m *Client) CreateReaderSegment(segment *audience.UploadingSegment, reader io.Reader, isCSV bool) error {
+	return m.CreateReaderSegmentContext(context.Background(), segment, reader, isCSV)
+}
+
+//CreateReaderSegmentContext - calls CreateReaderSegmentFunc
+func (m *Client) CreateReaderSegmentContext(ctx context.Context, segment *audience.UploadingSegment, reader io.Reader, isCSV bool) error {
+	m.record("CreateReaderSegment", segment, reader, isCSV)
+	if m.CreateReaderSegmentFunc == nil {
+		return notMocked("CreateReaderSegment")
+	}
+	return m.CreateReaderSegmentFunc(ctx, segment, reader, isCSV)
+}
+
+//ModifySegmentData - calls ModifySegmentDataFunc
+func (m *Client) ModifySegmentData(segment *audience.UploadingSegment, reader io.Reader, modificationType string) error {
+	return m.ModifySegmentDataContext(context.Background(), segment, reader, modificationType)
+}
+
+//ModifySegmentDataContext - calls ModifySegmentDataFunc
+func (m *Client) ModifySegmentDataContext(ctx context.Context, segment *audience.UploadingSegment, reader io.Reader, modificationType string) error {
+	m.record("ModifySegmentData", segment, reader, modificationType)
+	if m.ModifySegmentDataFunc == nil {
+		return notMocked("ModifySegmentData")
+	}
+	return m.ModifySegmentDataFunc(ctx, segment, reader, modificationType)
+}
+
+//SaveUploadedSegment - calls SaveUploadedSegmentFunc
+func (m *Client) SaveUploadedSegment(segment *audience.UploadingSegment) error {
+	return m.SaveUploadedSegmentContext(context.Background(), segment)
+}
+
+//SaveUploadedSegmentContext - calls SaveUploadedSegmentFunc
+func (m *Client) SaveUploadedSegmentContext(ctx context.Context, segment *audience.UploadingSegment) error {
+	m.record("SaveUploadedSegment", segment)
+	if m.SaveUploadedSegmentFunc == nil {
+		return notMocked("SaveUploadedSegment")
+	}
+	return m.SaveUploadedSegmentFunc(ctx, segment)
+}
+
+//SaveClientIDSegment - calls SaveClientIDSegmentFunc
+func (m *Client) SaveClientIDSegment(segment *audience.ClientIDSegment) error {
+	return m.SaveClientIDSegmentContext(context.Background(), segment)
+}
+
+//SaveClientIDSegmentContext - calls SaveClientIDSegmentFunc
+func (m *Client) SaveClientIDSegmentContext(ctx context.Context, segment *audience.ClientIDSegment) error {
+	m.record("SaveClientIDSegment", segment)
+	if m.SaveClientIDSegmentFunc == nil {
+		return notMocked("SaveClientIDSegment")
+	}
+	return m.SaveClientIDSegmentFunc(ctx, segment)
+}
+
+//RemoveSegment - calls RemoveSegmentFunc
+func (m *Client) RemoveSegment(id int64) error {
+	return m.RemoveSegmentContext(context.Background(), id)
+}
+
+//RemoveSegmentContext - calls RemoveSegmentFunc
+func (m *Client) RemoveSegmentContext(ctx context.Context, id int64) error {
+	m.record("RemoveSegment", id)
+	if m.RemoveSegmentFunc == nil {
+		return notMocked("RemoveSegment")
+	}
+	return m.RemoveSegmentFunc(ctx, id)
+}
+
+//CreatePixelSegment - calls CreatePixelSegmentFunc
+func (m *Client) CreatePixelSegment(segment *audience.PixelSegment) error {
+	return m.CreatePixelSegmentContext(context.Background(), segment)
+}
+
+//CreatePixelSegmentContext - calls CreatePixelSegmentFunc
+func (m *Client) CreatePixelSegmentContext(ctx context.Context, segment *audience.PixelSegment) error {
+	m.record("CreatePixelSegment", segment)
+	if m.CreatePixelSegmentFunc == nil {
+		return notMocked("CreatePixelSegment")
+	}
+	return m.CreatePixelSegmentFunc(ctx, segment)
+}
+
+//CreateLookalikeSegment - calls CreateLookalikeSegmentFunc
+func (m *Client) CreateLookalikeSegment(segment *audience.LookalikeSegment) error {
+	return m.CreateLookalikeSegmentContext(context.Background(), segment)
+}
+
+//CreateLookalikeSegmentContext - calls CreateLookalikeSegmentFunc
+func (m *Client) CreateLookalikeSegmentContext(ctx context.Context, segment *audience.LookalikeSegment) error {
+	m.record("CreateLookalikeSegment", segment)
+	if m.CreateLookalikeSegmentFunc == nil {
+		return notMocked("CreateLookalikeSegment")
+	}
+	return m.CreateLookalikeSegmentFunc(ctx, segment)
+}
+
+//CreateMetrikaSegment - calls CreateMetrikaSegmentFunc
+func (m *Client) CreateMetrikaSegment(segment *audience.MetrikaSegment) error {
+	return m.CreateMetrikaSegmentContext(context.Background(), segment)
+}
+
+//CreateMetrikaSegmentContext - calls CreateMetrikaSegmentFunc
+func (m *Client) CreateMetrikaSegmentContext(ctx context.Context, segment *audience.MetrikaSegment) error {
+	m.record("CreateMetrikaSegment", segment)
+	if m.CreateMetrikaSegmentFunc == nil {
+		return notMocked("CreateMetrikaSegment")
+	}
+	return m.CreateMetrikaSegmentFunc(ctx, segment)
+}
+
+//CreateAppMetrikaSegment - calls CreateAppMetrikaSegmentFunc
+func (m *Client) CreateAppMetrikaSegment(segment *audience.AppMetricaSegment) error {
+	return m.CreateAppMetrikaSegmentContext(context.Background(), segment)
+}
+
+//CreateAppMetrikaSegmentContext - calls CreateAppMetrikaSegmentFunc
+func (m *Client) CreateAppMetrikaSegmentContext(ctx context.Context, segment *audience.AppMetricaSegment) error {
+	m.record("CreateAppMetrikaSegment", segment)
+	if m.CreateAppMetrikaSegmentFunc == nil {
+		return notMocked("CreateAppMetrikaSegment")
+	}
+	return m.CreateAppMetrikaSegmentFunc(ctx, segment)
+}
+
+//CreateCircleGeoSegment - calls CreateCircleGeoSegmentFunc
+func (m *Client) CreateCircleGeoSegment(segment *audience.CircleGeoSegment) error {
+	return m.CreateCircleGeoSegmentContext(context.Background(), segment)
+}
+
+//CreateCircleGeoSegmentContext - calls CreateCircleGeoSegmentFunc
+func (m *Client) CreateCircleGeoSegmentContext(ctx context.Context, segment *audience.CircleGeoSegment) error {
+	m.record("CreateCircleGeoSegment", segment)
+	if m.CreateCircleGeoSegmentFunc == nil {
+		return notMocked("CreateCircleGeoSegment")
+	}
+	return m.CreateCircleGeoSegmentFunc(ctx, segment)
+}
+
+//CreatePolygonGeoSegment - calls CreatePolygonGeoSegmentFunc
+func (m *Client) CreatePolygonGeoSegment(segment *audience.PolygonGeoSegment) error {
+	return m.CreatePolygonGeoSegmentContext(context.Background(), segment)
+}
+
+//CreatePolygonGeoSegmentContext - calls CreatePolygonGeoSegmentFunc
+func (m *Client) CreatePolygonGeoSegmentContext(ctx context.Context, segment *audience.PolygonGeoSegment) error {
+	m.record("CreatePolygonGeoSegment", segment)
+	if m.CreatePolygonGeoSegmentFunc == nil {
+		return notMocked("CreatePolygonGeoSegment")
+	}
+	return m.CreatePolygonGeoSegmentFunc(ctx, segment)
+}
+
+//UpdateSegment - calls UpdateSegmentFunc
+func (m *Client) UpdateSegment(ID int64, segment interface{}) error {
+	return m.UpdateSegmentContext(context.Background(), ID, segment)
+}
+
+//UpdateSegmentContext - calls UpdateSegmentFunc
+func (m *Client) UpdateSegmentContext(ctx context.Context, ID int64, segment interface{}) error {
+	m.record("UpdateSegment", ID, segment)
+	if m.UpdateSegmentFunc == nil {
+		return notMocked("UpdateSegment")
+	}
+	return m.UpdateSegmentFunc(ctx, ID, segment)
+}
+
+//ReprocessSegment - calls ReprocessSegmentFunc
+func (m *Client) ReprocessSegment(segmentID int64) error {
+	return m.ReprocessSegmentContext(context.Background(), segmentID)
+}
+
+//ReprocessSegmentContext - calls ReprocessSegmentFunc
+func (m *Client) ReprocessSegmentContext(ctx context.Context, segmentID int64) error {
+	m.record("ReprocessSegment", segmentID)
+	if m.ReprocessSegmentFunc == nil {
+		return notMocked("ReprocessSegment")
+	}
+	return m.ReprocessSegmentFunc(ctx, segmentID)
+}
+
+//UpdateGeoPoints - calls UpdateGeoPointsFunc
+func (m *Client) UpdateGeoPoints(segment *audience.CircleGeoSegment, modificationType string) error {
+	return m.UpdateGeoPointsContext(context.Background(), segment, modificationType)
+}
+
+//UpdateGeoPointsContext - calls UpdateGeoPointsFunc
+func (m *Client) UpdateGeoPointsContext(ctx context.Context, segment *audience.CircleGeoSegment, modificationType string) error {
+	m.record("UpdateGeoPoints", segment, modificationType)
+	if m.UpdateGeoPointsFunc == nil {
+		return notMocked("UpdateGeoPoints")
+	}
+	return m.UpdateGeoPointsFunc(ctx, segment, modificationType)
+}
+
+//WaitForSegment - calls WaitForSegmentFunc
+func (m *Client) WaitForSegment(ctx context.Context, id int64, opts audience.WaitOptions) (audience.Segment, error) {
+	m.record("WaitForSegment", id, opts)
+	if m.WaitForSegmentFunc == nil {
+		return nil, notMocked("WaitForSegment")
+	}
+	return m.WaitForSegmentFunc(ctx, id, opts)
+}
+
+//PixelsList - calls PixelsListFunc
+func (m *Client) PixelsList() ([]*audience.Pixel, error) {
+	return m.PixelsListContext(context.Background())
+}
+
+//PixelsListContext - calls PixelsListFunc
+func (m *Client) PixelsListContext(ctx context.Context) ([]*audience.Pixel, error) {
+	m.record("PixelsList")
+	if m.PixelsListFunc == nil {
+		return nil, notMocked("PixelsList")
+	}
+	return m.PixelsListFunc(ctx)
+}
+
+//CreatePixel - calls CreatePixelFunc
+func (m *Client) CreatePixel(pixel *audience.Pixel) error {
+	return m.CreatePixelContext(context.Background(), pixel)
+}
+
+//CreatePixelContext - calls CreatePixelFunc
+func (m *Client) CreatePixelContext(ctx context.Context, pixel *audience.Pixel) error {
+	m.record("CreatePixel", pixel)
+	if m.CreatePixelFunc == nil {
+		return notMocked("CreatePixel")
+	}
+	return m.CreatePixelFunc(ctx, pixel)
+}
+
+//RemovePixel - calls RemovePixelFunc
+func (m *Client) RemovePixel(pixelID int64) error {
+	return m.RemovePixelContext(context.Background(), pixelID)
+}
+
+//RemovePixelContext - calls RemovePixelFunc
+func (m *Client) RemovePixelContext(ctx context.Context, pixelID int64) error {
+	m.record("RemovePixel", pixelID)
+	if m.RemovePixelFunc == nil {
+		return notMocked("RemovePixel")
+	}
+	return m.RemovePixelFunc(ctx, pixelID)
+}
+
+//UpdatePixel - calls UpdatePixelFunc
+func (m *Client) UpdatePixel(pixel *audience.Pixel) error {
+	return m.UpdatePixelContext(context.Background(), pixel)
+}
+
+//UpdatePixelContext - calls UpdatePixelFunc
+func (m *Client) UpdatePixelContext(ctx context.Context, pixel *audience.Pixel) error {
+	m.record("UpdatePixel", pixel)
+	if m.UpdatePixelFunc == nil {
+		return notMocked("UpdatePixel")
+	}
+	return m.UpdatePixelFunc(ctx, pixel)
+}
+
+//UndeletePixel - calls UndeletePixelFunc
+func (m *Client) UndeletePixel(pixelID int64) error {
+	return m.UndeletePixelContext(context.Background(), pixelID)
+}
+
+//UndeletePixelContext - calls UndeletePixelFunc
+func (m *Client) UndeletePixelContext(ctx context.Context, pixelID int64) error {
+	m.record("UndeletePixel", pixelID)
+	if m.UndeletePixelFunc == nil {
+		return notMocked("UndeletePixel")
+	}
+	return m.UndeletePixelFunc(ctx, pixelID)
+}
+
+//GrantsList - calls GrantsListFunc
+func (m *Client) GrantsList(segmentID int64) ([]*audience.Grant, error) {
+	return m.GrantsListContext(context.Background(), segmentID)
+}
+
+//GrantsListContext - calls GrantsListFunc
+func (m *Client) GrantsListContext(ctx context.Context, segmentID int64) ([]*audience.Grant, error) {
+	m.record("GrantsList", segmentID)
+	if m.GrantsListFunc == nil {
+		return nil, notMocked("GrantsList")
+	}
+	return m.GrantsListFunc(ctx, segmentID)
+}
+
+//CreateGrant - calls CreateGrantFunc
+func (m *Client) CreateGrant(segmentID int64, grant *audience.Grant) error {
+	return m.CreateGrantContext(context.Background(), segmentID, grant)
+}
+
+//CreateGrantContext - calls CreateGrantFunc
+func (m *Client) CreateGrantContext(ctx context.Context, segmentID int64, grant *audience.Grant) error {
+	m.record("CreateGrant", segmentID, grant)
+	if m.CreateGrantFunc == nil {
+		return notMocked("CreateGrant")
+	}
+	return m.CreateGrantFunc(ctx, segmentID, grant)
+}
+
+//RemoveGrant - calls RemoveGrantFunc
+func (m *Client) RemoveGrant(segmentID int64, userLogin string) error {
+	return m.RemoveGrantContext(context.Background(), segmentID, userLogin)
+}
+
+//RemoveGrantContext - calls RemoveGrantFunc
+func (m *Client) RemoveGrantContext(ctx context.Context, segmentID int64, userLogin string) error {
+	m.record("RemoveGrant", segmentID, userLogin)
+	if m.RemoveGrantFunc == nil {
+		return notMocked("RemoveGrant")
+	}
+	return m.RemoveGrantFunc(ctx, segmentID, userLogin)
+}
+
+//DelegatesList - calls DelegatesListFunc
+func (m *Client) DelegatesList() ([]*audience.Delegate, error) {
+	return m.DelegatesListContext(context.Background())
+}
+
+//DelegatesListContext - calls DelegatesListFunc
+func (m *Client) DelegatesListContext(ctx context.Context) ([]*audience.Delegate, error) {
+	m.record("DelegatesList")
+	if m.DelegatesListFunc == nil {
+		return nil, notMocked("DelegatesList")
+	}
+	return m.DelegatesListFunc(ctx)
+}
+
+//CreateDelegate - calls CreateDelegateFunc
+func (m *Client) CreateDelegate(delegate *audience.Delegate) error {
+	return m.CreateDelegateContext(context.Background(), delegate)
+}
+
+//CreateDelegateContext - calls CreateDelegateFunc
+func (m *Client) CreateDelegateContext(ctx context.Context, delegate *audience.Delegate) error {
+	m.record("CreateDelegate", delegate)
+	if m.CreateDelegateFunc == nil {
+		return notMocked("CreateDelegate")
+	}
+	return m.CreateDelegateFunc(ctx, delegate)
+}
+
+//RemoveDelegate - calls RemoveDelegateFunc
+func (m *Client) RemoveDelegate(userLogin string) error {
+	return m.RemoveDelegateContext(context.Background(), userLogin)
+}
+
+//RemoveDelegateContext - calls RemoveDelegateFunc
+func (m *Client) RemoveDelegateContext(ctx context.Context, userLogin string) error {
+	m.record("RemoveDelegate", userLogin)
+	if m.RemoveDelegateFunc == nil {
+		return notMocked("RemoveDelegate")
+	}
+	return m.RemoveDelegateFunc(ctx, userLogin)
+}
+
+//AccountsList - calls AccountsListFunc
+func (m *Client) AccountsList() ([]*audience.Account, error) {
+	return m.AccountsListContext(context.Background())
+}
+
+//AccountsListContext - calls AccountsListFunc
+func (m *Client) AccountsListContext(ctx context.Context) ([]*audience.Account, error) {
+	m.record("AccountsList")
+	if m.AccountsListFunc == nil {
+		return nil, notMocked("AccountsList")
+	}
+	return m.AccountsListFunc(ctx)
+}
+
+//Call - calls CallFunc
+func (m *Client) Call(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	m.record("Call", method, path, query, in)
+	if m.CallFunc == nil {
+		return notMocked("Call")
+	}
+	return m.CallFunc(ctx, method, path, query, in, out)
+}
+
+//Close - calls CloseFunc (returns nil if it isn't set)
+func (m *Client) Close() error {
+	m.record("Close")
+	if m.CloseFunc == nil {
+		return nil
+	}
+	return m.CloseFunc()
+}
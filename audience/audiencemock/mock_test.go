@@ -0,0 +1,51 @@
+package audiencemock
+
+import (
+	"context"
+	"errors"
+	"github.com/nikon72ru/yandex-audience-api/audience"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func removeAll(api audience.SegmentService, ids ...int64) error {
+	for _, id := range ids {
+		if err := api.RemoveSegment(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	Convey("mock client", t, func() {
+		mock := &Client{}
+		Convey("mocked method", func() {
+			mock.RemoveSegmentFunc = func(ctx context.Context, id int64) error {
+				if id == 2 {
+					return audience.ErrNotDeleted
+				}
+				return nil
+			}
+			So(removeAll(mock, 1, 2, 3), ShouldEqual, audience.ErrNotDeleted)
+			calls := mock.CallsOf("RemoveSegment")
+			So(calls, ShouldHaveLength, 2)
+			So(calls[1].Args, ShouldResemble, []interface{}{int64(2)})
+		})
+		Convey("list method", func() {
+			mock.SegmentsListFunc = func(ctx context.Context, pixel ...int) ([]audience.Segment, error) {
+				return []audience.Segment{&audience.PixelSegment{PixelID: pixel[0]}}, nil
+			}
+			segments, err := mock.SegmentsList(7)
+			So(err, ShouldBeNil)
+			So(segments[0].(*audience.PixelSegment).PixelID, ShouldEqual, 7)
+		})
+		Convey("not mocked method", func() {
+			_, err := mock.PixelsList()
+			So(errors.Is(err, ErrNotMocked), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "PixelsList")
+			So(mock.Close(), ShouldBeNil)
+			So(mock.Calls(), ShouldHaveLength, 2)
+		})
+	})
+}
@@ -0,0 +1,142 @@
+//Package audienceprom - Prometheus metrics of Yandex Audience client calls.
+//
+//	collector := audienceprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	client, _ := audience.NewClient(ctx, audience.WithMetrics(collector))
+package audienceprom
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/nikon72ru/yandex-audience-api/audience"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Status label values used when the API hasn't responded
+const (
+	StatusOK            = "ok"
+	StatusCanceled      = "canceled"
+	StatusTimeout       = "timeout"
+	StatusQuotaExceeded = "quota_exceeded"
+	StatusNetworkError  = "network_error"
+	StatusError         = "error"
+)
+
+//UnknownErrorType - error_type label value of API errors without error types
+const UnknownErrorType = "unknown"
+
+//Collector - prometheus.Collector fed by the client (see audience.WithMetrics)
+type Collector struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	uploaded  *prometheus.CounterVec
+	retries   *prometheus.CounterVec
+	apiErrors *prometheus.CounterVec
+}
+
+var _ audience.Metrics = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+//NewCollector - creates a collector with metrics in "audience" namespace:
+//requests_total{operation,status}, request_duration_seconds{operation}, uploaded_bytes_total{operation},
+//retries_total{operation,status} and api_errors_total{operation,error_type}.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "audience",
+			Name:      "requests_total",
+			Help:      "Yandex Audience client calls by operation and HTTP status.",
+		}, []string{"operation", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "audience",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of Yandex Audience client calls including retries.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"operation"}),
+		uploaded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "audience",
+			Name:      "uploaded_bytes_total",
+			Help:      "Bytes of segment files uploaded to Yandex Audience.",
+		}, []string{"operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "audience",
+			Name:      "retries_total",
+			Help:      "Retried Yandex Audience requests by operation and HTTP status of the failed attempt.",
+		}, []string{"operation", "status"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "audience",
+			Name:      "api_errors_total",
+			Help:      "Yandex Audience API errors by operation and error type.",
+		}, []string{"operation", "error_type"}),
+	}
+}
+
+//Describe - implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.latency.Describe(ch)
+	c.uploaded.Describe(ch)
+	c.retries.Describe(ch)
+	c.apiErrors.Describe(ch)
+}
+
+//Collect - implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.latency.Collect(ch)
+	c.uploaded.Collect(ch)
+	c.retries.Collect(ch)
+	c.apiErrors.Collect(ch)
+}
+
+//ObserveOperation - implements audience.Metrics
+func (c *Collector) ObserveOperation(stats audience.OperationStats) {
+	c.requests.WithLabelValues(stats.Operation, status(stats.StatusCode, stats.Err)).Inc()
+	c.latency.WithLabelValues(stats.Operation).Observe(stats.Duration.Seconds())
+	if stats.UploadedBytes > 0 {
+		c.uploaded.WithLabelValues(stats.Operation).Add(float64(stats.UploadedBytes))
+	}
+	var apiErr *audience.APIError
+	if errors.As(stats.Err, &apiErr) {
+		types := apiErr.ErrorTypes()
+		if len(types) == 0 {
+			types = []string{UnknownErrorType}
+		}
+		for _, errorType := range types {
+			c.apiErrors.WithLabelValues(stats.Operation, errorType).Inc()
+		}
+	}
+}
+
+//ObserveRetry - implements audience.Metrics
+func (c *Collector) ObserveRetry(operation string, statusCode int) {
+	label := StatusNetworkError
+	if statusCode != 0 {
+		label = strconv.Itoa(statusCode)
+	}
+	c.retries.WithLabelValues(operation, label).Inc()
+}
+
+//status - returns the status label: HTTP status or the reason why the API hasn't responded
+func status(statusCode int, err error) string {
+	var netErr net.Error
+	switch {
+	case statusCode != 0:
+		return strconv.Itoa(statusCode)
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, context.Canceled):
+		return StatusCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusTimeout
+	case errors.Is(err, audience.ErrQuotaExceeded):
+		return StatusQuotaExceeded
+	case errors.As(err, &netErr):
+		return StatusNetworkError
+	default:
+		return StatusError
+	}
+}
@@ -0,0 +1,84 @@
+package audienceprom
+
+import (
+	"context"
+	"errors"
+	"github.com/nikon72ru/yandex-audience-api/audience"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	Convey("metrics of client calls", t, func() {
+		var pixelCalls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/management/pixels":
+				if atomic.AddInt32(&pixelCalls, 1) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(`{"pixels":[]}`))
+			case "/v1/management/segments/upload_file":
+				_, _ = ioutil.ReadAll(r.Body)
+				_, _ = w.Write([]byte(`{"segment":{"id":12}}`))
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":[{"error_type":"invalid_parameter","message":"bad name"}],"code":400,"message":"bad name"}`))
+			}
+		}))
+		defer ts.Close()
+		collector := NewCollector()
+		client, err := audience.NewClient(context.Background(),
+			audience.WithToken("token"),
+			audience.WithBaseURL(ts.URL),
+			audience.WithMetrics(collector),
+			audience.WithRetryPolicy(audience.RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		)
+		So(err, ShouldBeNil)
+		Convey("requests, latency and retries", func() {
+			_, err := client.PixelsList()
+			So(err, ShouldBeNil)
+			So(testutil.ToFloat64(collector.requests.WithLabelValues("PixelsList", "200")), ShouldEqual, 1)
+			So(testutil.ToFloat64(collector.retries.WithLabelValues("PixelsList", "503")), ShouldEqual, 1)
+			So(testutil.CollectAndCount(collector.latency), ShouldEqual, 1)
+		})
+		Convey("uploaded bytes", func() {
+			So(client.CreateReaderSegment(&audience.UploadingSegment{}, strings.NewReader("a\nb\n"), false), ShouldBeNil)
+			So(testutil.ToFloat64(collector.uploaded.WithLabelValues("CreateReaderSegment")), ShouldEqual, 4)
+		})
+		Convey("API errors by type", func() {
+			So(client.RemoveSegment(42), ShouldNotBeNil)
+			So(testutil.ToFloat64(collector.requests.WithLabelValues("RemoveSegment", "400")), ShouldEqual, 1)
+			So(testutil.ToFloat64(collector.apiErrors.WithLabelValues("RemoveSegment", audience.ErrorTypeInvalidParameter)), ShouldEqual, 1)
+		})
+		Convey("registered collector", func() {
+			registry := prometheus.NewPedanticRegistry()
+			So(registry.Register(collector), ShouldBeNil)
+			_, _ = client.PixelsList()
+			count, err := testutil.GatherAndCount(registry, "audience_requests_total", "audience_retries_total")
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestStatus(t *testing.T) {
+	Convey("status label", t, func() {
+		So(status(http.StatusTooManyRequests, errors.New("quota")), ShouldEqual, "429")
+		So(status(0, nil), ShouldEqual, StatusOK)
+		So(status(0, context.Canceled), ShouldEqual, StatusCanceled)
+		So(status(0, context.DeadlineExceeded), ShouldEqual, StatusTimeout)
+		So(status(0, &audience.QuotaError{}), ShouldEqual, StatusQuotaExceeded)
+		So(status(0, errors.New("broken")), ShouldEqual, StatusError)
+	})
+}
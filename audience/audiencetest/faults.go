@@ -0,0 +1,169 @@
+package audiencetest
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/nikon72ru/yandex-audience-api/audience"
+)
+
+//FaultKind - what the server does instead of the normal response
+type FaultKind int
+
+//Fault kinds
+const (
+	//FaultStatus - responds with StatusCode and the API error of this status (quota error for 429, backend error for 5xx)
+	FaultStatus FaultKind = iota
+	//FaultDelay - waits Delay before handling the request
+	FaultDelay
+	//FaultTruncate - handles the request, but sends only the first half of the response body
+	FaultTruncate
+	//FaultDrop - reads the beginning of the request body (DropAfter bytes) and closes the connection without response
+	FaultDrop
+	//FaultUnsuccessful - responds {"success":false} without changing anything (RemoveSegment, UndeletePixel and so on)
+	FaultUnsuccessful
+)
+
+//QuotaErrorType - error type of 429 responses
+const QuotaErrorType = audience.ErrorTypeQuotaPrefix + "requests_by_uid"
+
+//defaultDropAfter - bytes of the request body read before the connection is dropped
+const defaultDropAfter = 1024
+
+//Fault - a scripted failure of the server. Add faults with Server.Inject:
+//	srv.Inject(audiencetest.Fault{Kind: audiencetest.FaultStatus, Path: "segments", Nth: 2, StatusCode: 503})
+type Fault struct {
+	Kind FaultKind
+	//Method - HTTP method of the failing requests, any method if empty
+	Method string
+	//Path - API path pattern without /v1/management/ prefix ("segments/upload_file", "pixel/{id}/undelete"),
+	//any path if empty
+	Path string
+	//Nth - the fault fires on the Nth matching call (1 is the first one), on every call if 0
+	Nth int
+	//Times - how many calls starting from the Nth one fail (1 if 0)
+	Times int
+	//StatusCode - status of FaultStatus
+	StatusCode int
+	//RetryAfter - Retry-After header of FaultStatus responses (not set if 0)
+	RetryAfter time.Duration
+	//Delay - delay of FaultDelay
+	Delay time.Duration
+	//DropAfter - bytes of the request body read before FaultDrop closes the connection (1024 if 0)
+	DropAfter int64
+}
+
+type faultState struct {
+	Fault
+	calls int
+}
+
+//fires - counts the matching call and reports whether the fault fires on it
+func (f *faultState) fires(method string, parts []string) bool {
+	if f.Method != "" && f.Method != method {
+		return false
+	}
+	if f.Path != "" && !match(f.Path, parts) {
+		return false
+	}
+	f.calls++
+	if f.Nth == 0 {
+		return true
+	}
+	times := f.Times
+	if times == 0 {
+		times = 1
+	}
+	return f.calls >= f.Nth && f.calls < f.Nth+times
+}
+
+//Inject - adds faults, the first matching fault fires if several faults match the call
+func (s *Server) Inject(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fault := range faults {
+		s.faults = append(s.faults, &faultState{Fault: fault})
+	}
+}
+
+//ClearFaults - removes all faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+//nextFault - returns the fault firing on the call
+func (s *Server) nextFault(method string, parts []string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fired *faultState
+	for _, fault := range s.faults {
+		//every matching fault counts the call
+		if fault.fires(method, parts) && fired == nil {
+			fired = fault
+		}
+	}
+	if fired == nil {
+		return Fault{}, false
+	}
+	return fired.Fault, true
+}
+
+func (s *Server) serveFault(w http.ResponseWriter, r *http.Request, parts []string, fault Fault) {
+	switch fault.Kind {
+	case FaultStatus:
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter/time.Second)))
+		}
+		errorType := audience.ErrorTypeInvalidParameter
+		switch {
+		case fault.StatusCode == http.StatusTooManyRequests:
+			errorType = QuotaErrorType
+		case fault.StatusCode >= http.StatusInternalServerError:
+			errorType = audience.ErrorTypeBackendError
+		case fault.StatusCode == http.StatusNotFound:
+			errorType = audience.ErrorTypeNotFound
+		case fault.StatusCode == http.StatusUnauthorized || fault.StatusCode == http.StatusForbidden:
+			errorType = audience.ErrorTypeAccessDenied
+		}
+		writeError(w, fault.StatusCode, errorType, "", http.StatusText(fault.StatusCode))
+	case FaultDelay:
+		timer := time.NewTimer(fault.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			s.handle(w, r, parts)
+		case <-r.Context().Done():
+		}
+	case FaultTruncate:
+		rec := httptest.NewRecorder()
+		s.handle(rec, r, parts)
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.Code)
+		body := rec.Body.Bytes()
+		_, _ = w.Write(body[:len(body)/2])
+	case FaultDrop:
+		dropAfter := fault.DropAfter
+		if dropAfter == 0 {
+			dropAfter = defaultDropAfter
+		}
+		_, _ = io.CopyN(ioutil.Discard, r.Body, dropAfter)
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+		//the server closes the connection of aborted handler
+		panic(http.ErrAbortHandler)
+	case FaultUnsuccessful:
+		writeJSON(w, map[string]bool{"success": false})
+	}
+}
@@ -0,0 +1,75 @@
+package audiencetest
+
+import (
+	"context"
+	"errors"
+	"github.com/nikon72ru/yandex-audience-api/audience"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestServer_Inject(t *testing.T) {
+	Convey("fault injection", t, func() {
+		srv := NewServer()
+		defer srv.Close()
+		client, err := srv.Client()
+		So(err, ShouldBeNil)
+		Convey("status on the Nth call", func() {
+			srv.Inject(Fault{Kind: FaultStatus, Method: http.MethodGet, Path: "pixels", Nth: 2, StatusCode: http.StatusTooManyRequests})
+			_, err := client.PixelsList()
+			So(err, ShouldBeNil)
+			_, err = client.PixelsList()
+			So(errors.Is(err, audience.ErrQuotaExceeded), ShouldBeTrue)
+			var apiErr *audience.APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.ErrorTypes(), ShouldResemble, []string{QuotaErrorType})
+			_, err = client.PixelsList()
+			So(err, ShouldBeNil)
+		})
+		Convey("retried server errors", func() {
+			srv.Inject(Fault{Kind: FaultStatus, Path: "segments", Nth: 1, Times: 2, StatusCode: http.StatusServiceUnavailable})
+			_, err := client.SegmentsList()
+			So(errors.Is(err, audience.ErrBackendError), ShouldBeTrue)
+			retrying, err := srv.Client(audience.WithRetryPolicy(audience.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+			So(err, ShouldBeNil)
+			_, err = retrying.SegmentsList()
+			So(err, ShouldBeNil)
+		})
+		Convey("slow response", func() {
+			srv.Inject(Fault{Kind: FaultDelay, Delay: time.Second})
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err := client.PixelsListContext(ctx)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+		Convey("truncated body", func() {
+			srv.Inject(Fault{Kind: FaultTruncate, Path: "pixels"})
+			So(client.CreatePixel(&audience.Pixel{Name: "pixel"}), ShouldNotBeNil)
+		})
+		Convey("dropped upload", func() {
+			srv.Inject(Fault{Kind: FaultDrop, Path: "segments/upload_file"})
+			f, err := os.Open("../../test-files/macs_for_uploads.csv")
+			So(err, ShouldBeNil)
+			defer f.Close()
+			So(client.CreateReaderSegment(&audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "macs"}}, f, false), ShouldNotBeNil)
+			segments, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			So(segments, ShouldBeEmpty)
+		})
+		Convey("unsuccessful responses", func() {
+			pixel := &audience.Pixel{Name: "pixel"}
+			So(client.CreatePixel(pixel), ShouldBeNil)
+			srv.Inject(
+				Fault{Kind: FaultUnsuccessful, Path: "pixel/{id}/undelete"},
+				Fault{Kind: FaultUnsuccessful, Method: http.MethodDelete, Path: "segment/{id}"},
+			)
+			So(client.UndeletePixel(pixel.ID), ShouldEqual, audience.ErrNotRestored)
+			So(client.RemoveSegment(1), ShouldEqual, audience.ErrNotDeleted)
+			srv.ClearFaults()
+			So(client.UndeletePixel(pixel.ID), ShouldBeNil)
+		})
+	})
+}
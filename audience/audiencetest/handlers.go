@@ -0,0 +1,541 @@
+package audiencetest
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nikon72ru/yandex-audience-api/audience"
+)
+
+//maxUploadSize - limit of the uploaded file size
+const maxUploadSize = 1 << 30
+
+//id - returns ID from the second part of the path (pixel/{id}, segment/{id}/...)
+func (r *request) id() int64 {
+	id, _ := strconv.ParseInt(r.parts[1], 10, 64)
+	return id
+}
+
+//decode - decodes the request body field, writes invalid_parameter error if it's malformed
+func decode(w http.ResponseWriter, r *request, field string, v interface{}) bool {
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "", "Invalid JSON: "+err.Error())
+		return false
+	}
+	raw, ok := body[field]
+	if !ok {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, field, "Field is required")
+		return false
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, field, "Invalid value: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func requireName(w http.ResponseWriter, name string) bool {
+	if strings.TrimSpace(name) == "" {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "name", "Name can't be empty")
+		return false
+	}
+	return true
+}
+
+func (r *request) pixel(w http.ResponseWriter) (*pixelState, bool) {
+	pixel, ok := r.account.pixels[r.id()]
+	if !ok {
+		writeError(w, http.StatusNotFound, audience.ErrorTypeNotFound, "pixel_id", "Pixel not found")
+	}
+	return pixel, ok
+}
+
+func (r *request) segment(w http.ResponseWriter) (*segmentState, bool) {
+	segment, ok := r.account.segments[r.id()]
+	if !ok {
+		writeError(w, http.StatusNotFound, audience.ErrorTypeNotFound, "segment_id", "Segment not found")
+	}
+	return segment, ok
+}
+
+func (s *Server) pixelsList(w http.ResponseWriter, r *request) {
+	ids := make([]int64, 0, len(r.account.pixels))
+	for id, pixel := range r.account.pixels {
+		if !pixel.deleted {
+			ids = append(ids, id)
+		}
+	}
+	pixels := make([]audience.Pixel, 0, len(ids))
+	for _, id := range sortedIDs(ids) {
+		pixels = append(pixels, r.account.pixels[id].pixel)
+	}
+	writeJSON(w, map[string]interface{}{"pixels": pixels})
+}
+
+func (s *Server) createPixel(w http.ResponseWriter, r *request) {
+	var pixel audience.Pixel
+	if !decode(w, r, "pixel", &pixel) || !requireName(w, pixel.Name) {
+		return
+	}
+	pixel.ID = s.newID()
+	pixel.CreateTime = s.Now()
+	r.account.pixels[pixel.ID] = &pixelState{pixel: pixel}
+	writeJSON(w, map[string]interface{}{"pixel": pixel})
+}
+
+func (s *Server) updatePixel(w http.ResponseWriter, r *request) {
+	pixel, ok := r.pixel(w)
+	if !ok {
+		return
+	}
+	var update audience.Pixel
+	if !decode(w, r, "pixel", &update) || !requireName(w, update.Name) {
+		return
+	}
+	pixel.pixel.Name = update.Name
+	writeJSON(w, map[string]interface{}{"pixel": pixel.pixel})
+}
+
+func (s *Server) removePixel(w http.ResponseWriter, r *request) {
+	pixel, ok := r.pixel(w)
+	if !ok {
+		return
+	}
+	pixel.deleted = true
+	writeSuccess(w)
+}
+
+func (s *Server) undeletePixel(w http.ResponseWriter, r *request) {
+	pixel, ok := r.pixel(w)
+	if !ok {
+		return
+	}
+	pixel.deleted = false
+	writeSuccess(w)
+}
+
+func (s *Server) segmentsList(w http.ResponseWriter, r *request) {
+	var pixelID int
+	if value := r.URL.Query().Get("pixel"); value != "" {
+		var err error
+		if pixelID, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "pixel", "Invalid pixel")
+			return
+		}
+	}
+	ids := make([]int64, 0, len(r.account.segments))
+	for id, segment := range r.account.segments {
+		if pixelID != 0 {
+			if pixelSegment, ok := segment.segment.(*audience.PixelSegment); !ok || pixelSegment.PixelID != pixelID {
+				continue
+			}
+		}
+		ids = append(ids, id)
+	}
+	segments := make([]json.RawMessage, 0, len(ids))
+	for _, id := range sortedIDs(ids) {
+		segments = append(segments, encodeSegment(r.account.segments[id].segment))
+	}
+	writeJSON(w, map[string]interface{}{"segments": segments})
+}
+
+//encodeSegment - encodes the segment with "type" field like the real API does
+func encodeSegment(segment audience.Segment) json.RawMessage {
+	data, _ := json.Marshal(segment)
+	var fields map[string]interface{}
+	_ = json.Unmarshal(data, &fields)
+	fields["type"] = segmentType(segment)
+	if fields["content_type"] == "" {
+		//content type of the uploaded file is unknown until it's confirmed
+		delete(fields, "content_type")
+	}
+	data, _ = json.Marshal(fields)
+	return data
+}
+
+func segmentType(segment audience.Segment) string {
+	switch segment.(type) {
+	case *audience.PixelSegment:
+		return "pixel"
+	case *audience.LookalikeSegment:
+		return "lookalike"
+	case *audience.MetrikaSegment:
+		return "metrika"
+	case *audience.AppMetricaSegment:
+		return "appmetrica"
+	case *audience.CircleGeoSegment, *audience.PolygonGeoSegment:
+		return "geo"
+	}
+	return "uploading"
+}
+
+func (s *Server) uploadSegment(w http.ResponseWriter, r *request) {
+	segment := &audience.UploadingSegment{BaseSegment: s.newBase(r, r.upload.filename, audience.SegmentStatusUploaded)}
+	r.account.segments[segment.ID] = &segmentState{segment: segment, records: r.upload.records}
+	writeJSON(w, map[string]interface{}{"segment": encodeSegment(segment)})
+}
+
+//readUpload - reads identifiers from the uploaded file, writes invalid_parameter error if the file is missing or empty
+func readUpload(w http.ResponseWriter, r *request) (*upload, bool) {
+	//the first line of CSV file is the header
+	isCSV := strings.HasSuffix(r.URL.Path, "upload_csv_file")
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "file", "File is required")
+		return nil, false
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	records := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if isCSV {
+			isCSV = false
+			continue
+		}
+		if record := strings.TrimSpace(scanner.Text()); record != "" {
+			records[record] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "file", "Can't read file: "+err.Error())
+		return nil, false
+	}
+	if len(records) == 0 {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "file", "File is empty")
+		return nil, false
+	}
+	return &upload{filename: header.Filename, records: records}, true
+}
+
+func (s *Server) modifySegmentData(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	uploading, ok := segment.segment.(*audience.UploadingSegment)
+	if !ok || uploading.Status == audience.SegmentStatusUploaded {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "segment_id", "Segment isn't a saved uploaded segment")
+		return
+	}
+	modificationType := r.URL.Query().Get("modification_type")
+	switch modificationType {
+	case audience.ModificationAddition, audience.ModificationSubtraction, audience.ModificationReplace:
+	default:
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "modification_type", "Unknown modification type")
+		return
+	}
+	records := r.upload.records
+	switch modificationType {
+	case audience.ModificationAddition:
+		for record := range records {
+			segment.records[record] = true
+		}
+	case audience.ModificationSubtraction:
+		for record := range records {
+			delete(segment.records, record)
+		}
+	case audience.ModificationReplace:
+		segment.records = records
+	}
+	uploading.Status = audience.SegmentStatusIsUpdated
+	writeJSON(w, map[string]interface{}{"segment": encodeSegment(uploading)})
+}
+
+func (s *Server) newBase(r *request, name, status string) audience.BaseSegment {
+	return audience.BaseSegment{
+		ID:         s.newID(),
+		Name:       name,
+		Status:     status,
+		CreateTime: s.Now(),
+		Owner:      r.login,
+	}
+}
+
+func (s *Server) confirmSegment(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	uploading, ok := segment.segment.(*audience.UploadingSegment)
+	if !ok || uploading.Status != audience.SegmentStatusUploaded {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "segment_id", "Segment is already confirmed")
+		return
+	}
+	var confirm audience.UploadingSegment
+	if !decode(w, r, "segment", &confirm) || !requireName(w, confirm.Name) {
+		return
+	}
+	switch confirm.ContentType {
+	case audience.IdfaGain, audience.ClientID, audience.Mac, audience.Crm:
+	default:
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "content_type", "Unknown content type")
+		return
+	}
+	uploading.Name = confirm.Name
+	uploading.Hashed = confirm.Hashed
+	uploading.ContentType = confirm.ContentType
+	uploading.Status = audience.SegmentStatusIsProcessed
+	writeJSON(w, map[string]interface{}{"segment": encodeSegment(uploading)})
+}
+
+func (s *Server) confirmClientIDSegment(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	uploading, ok := segment.segment.(*audience.UploadingSegment)
+	if !ok || uploading.Status != audience.SegmentStatusUploaded {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "segment_id", "Segment is already confirmed")
+		return
+	}
+	var confirm audience.ClientIDSegment
+	if !decode(w, r, "segment", &confirm) || !requireName(w, confirm.Name) {
+		return
+	}
+	if confirm.CounterID <= 0 {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "counter_id", "Counter not found")
+		return
+	}
+	if confirm.ContentType != audience.ClientID {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "content_type", "Content type must be client_id")
+		return
+	}
+	uploading.Name = confirm.Name
+	uploading.ContentType = audience.ClientID
+	uploading.Status = audience.SegmentStatusIsProcessed
+	fields := map[string]interface{}{}
+	_ = json.Unmarshal(encodeSegment(uploading), &fields)
+	fields["counter_id"] = confirm.CounterID
+	writeJSON(w, map[string]interface{}{"segment": fields})
+}
+
+//createSegment - returns handler creating segments of the type
+func (s *Server) createSegment(newSegment func() audience.Segment) handlerFunc {
+	return func(w http.ResponseWriter, r *request) {
+		segment := newSegment()
+		if !decode(w, r, "segment", segment) || !requireName(w, segment.Base().Name) || !s.validateSegment(w, r, segment) {
+			return
+		}
+		*segment.Base() = s.newBase(r, segment.Base().Name, audience.SegmentStatusIsProcessed)
+		r.account.segments[segment.Base().ID] = &segmentState{segment: segment}
+		writeJSON(w, map[string]interface{}{"segment": encodeSegment(segment)})
+	}
+}
+
+//validateSegment - checks the fields of the segment which the real API checks
+func (s *Server) validateSegment(w http.ResponseWriter, r *request, segment audience.Segment) bool {
+	switch segment := segment.(type) {
+	case *audience.PixelSegment:
+		if pixel, ok := r.account.pixels[int64(segment.PixelID)]; !ok || pixel.deleted {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "pixel_id", "Pixel not found")
+			return false
+		}
+	case *audience.LookalikeSegment:
+		if _, ok := r.account.segments[segment.LookalikeLink]; !ok {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "lookalike_link", "Segment not found")
+			return false
+		}
+		if segment.LookalikeValue < 1 || segment.LookalikeValue > 5 {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "lookalike_value", "Value must be from 1 to 5")
+			return false
+		}
+	case *audience.CircleGeoSegment:
+		if len(segment.Points) == 0 {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "points", "Points can't be empty")
+			return false
+		}
+	case *audience.PolygonGeoSegment:
+		if len(segment.Polygons) == 0 {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "polygons", "Polygons can't be empty")
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) updateSegment(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	var update audience.BaseSegment
+	if !decode(w, r, "segment", &update) || !requireName(w, update.Name) {
+		return
+	}
+	segment.segment.Base().Name = update.Name
+	writeJSON(w, map[string]interface{}{"segment": encodeSegment(segment.segment)})
+}
+
+func (s *Server) removeSegment(w http.ResponseWriter, r *request) {
+	if _, ok := r.segment(w); !ok {
+		return
+	}
+	delete(r.account.segments, r.id())
+	writeSuccess(w)
+}
+
+func (s *Server) reprocessSegment(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	base := segment.segment.Base()
+	if base.Status != audience.SegmentStatusProcessed && base.Status != audience.SegmentStatusFewData {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "segment_id", "Segment isn't processed yet")
+		return
+	}
+	base.Status = audience.SegmentStatusIsProcessed
+	writeSuccess(w)
+}
+
+func (s *Server) updateGeoPoints(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	geo, ok := segment.segment.(*audience.CircleGeoSegment)
+	if !ok {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "segment_id", "Segment isn't a circle geo segment")
+		return
+	}
+	modificationType := r.URL.Query().Get("modification_type")
+	if modificationType != audience.ModificationAddition && modificationType != audience.ModificationReplace {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "modification_type", "Modification type must be addition or replace")
+		return
+	}
+	var update audience.CircleGeoSegment
+	if !decode(w, r, "segment", &update) {
+		return
+	}
+	if update.Radius < audience.MinGeoRadius || update.Radius > audience.MaxGeoRadius {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "radius", "Radius must be from 500 to 10000")
+		return
+	}
+	if len(update.Points) == 0 {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "points", "Points can't be empty")
+		return
+	}
+	for _, point := range update.Points {
+		if point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180 {
+			writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "points", "Invalid coordinates")
+			return
+		}
+	}
+	if modificationType == audience.ModificationReplace {
+		geo.Points = nil
+	}
+	geo.Points = append(geo.Points, update.Points...)
+	geo.Radius = update.Radius
+	geo.Status = audience.SegmentStatusIsUpdated
+	writeJSON(w, map[string]interface{}{"segment": encodeSegment(geo)})
+}
+
+func (s *Server) grantsList(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	grants := segment.grants
+	if grants == nil {
+		grants = []*audience.Grant{}
+	}
+	writeJSON(w, map[string]interface{}{"grants": grants})
+}
+
+func (s *Server) createGrant(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	var grant audience.Grant
+	if !decode(w, r, "grant", &grant) {
+		return
+	}
+	if grant.UserLogin == "" {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "user_login", "Login can't be empty")
+		return
+	}
+	grant.CreatedAt = s.Now()
+	for i, existing := range segment.grants {
+		if existing.UserLogin == grant.UserLogin {
+			segment.grants[i] = &grant
+			writeJSON(w, map[string]interface{}{"grant": grant})
+			return
+		}
+	}
+	segment.grants = append(segment.grants, &grant)
+	writeJSON(w, map[string]interface{}{"grant": grant})
+}
+
+func (s *Server) removeGrant(w http.ResponseWriter, r *request) {
+	segment, ok := r.segment(w)
+	if !ok {
+		return
+	}
+	login := r.URL.Query().Get("user_login")
+	for i, grant := range segment.grants {
+		if grant.UserLogin == login {
+			segment.grants = append(segment.grants[:i], segment.grants[i+1:]...)
+			writeSuccess(w)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, audience.ErrorTypeNotFound, "user_login", "Grant not found")
+}
+
+func (s *Server) delegatesList(w http.ResponseWriter, r *request) {
+	delegates := r.account.delegates
+	if delegates == nil {
+		delegates = []*audience.Delegate{}
+	}
+	writeJSON(w, map[string]interface{}{"delegates": delegates})
+}
+
+func (s *Server) createDelegate(w http.ResponseWriter, r *request) {
+	var delegate audience.Delegate
+	if !decode(w, r, "delegate", &delegate) {
+		return
+	}
+	if delegate.UserLogin == "" {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "user_login", "Login can't be empty")
+		return
+	}
+	if delegate.Perm != audience.View && delegate.Perm != audience.Edit {
+		writeError(w, http.StatusBadRequest, audience.ErrorTypeInvalidParameter, "perm", "Perm must be view or edit")
+		return
+	}
+	delegate.CreatedAt = s.Now()
+	for i, existing := range r.account.delegates {
+		if existing.UserLogin == delegate.UserLogin {
+			r.account.delegates[i] = &delegate
+			writeJSON(w, map[string]interface{}{"delegate": delegate})
+			return
+		}
+	}
+	r.account.delegates = append(r.account.delegates, &delegate)
+	writeJSON(w, map[string]interface{}{"delegate": delegate})
+}
+
+func (s *Server) removeDelegate(w http.ResponseWriter, r *request) {
+	login := r.URL.Query().Get("user_login")
+	for i, delegate := range r.account.delegates {
+		if delegate.UserLogin == login {
+			r.account.delegates = append(r.account.delegates[:i], r.account.delegates[i+1:]...)
+			writeSuccess(w)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, audience.ErrorTypeNotFound, "user_login", "Delegate not found")
+}
+
+func (s *Server) accountsList(w http.ResponseWriter, r *request) {
+	accounts := append([]audience.Account{}, s.granted...)
+	writeJSON(w, map[string]interface{}{"accounts": accounts})
+}
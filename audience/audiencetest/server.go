@@ -0,0 +1,344 @@
+//Package audiencetest - in-memory Yandex Audience API server for tests.
+//
+//The server keeps pixels, segments, grants, delegates and accounts, validates requests
+//and responds with the same payloads (including errors) as the real API:
+//	srv := audiencetest.NewServer()
+//	defer srv.Close()
+//	client, _ := srv.Client()
+//	_ = client.CreatePixel(&audience.Pixel{Name: "pixel"})
+package audiencetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikon72ru/yandex-audience-api/audience"
+)
+
+//DefaultToken - OAuth token accepted by the server created by NewServer
+const DefaultToken = "audiencetest-token"
+
+//DefaultLogin - login of the token owner
+const DefaultLogin = "audiencetest"
+
+//DefaultFewDataThreshold - uploaded segments with fewer records get few_data status when processed
+const DefaultFewDataThreshold = 1000
+
+//Server - in-memory Yandex Audience API. Use Client to get the client working with it.
+type Server struct {
+	*httptest.Server
+	//Token - OAuth token the server accepts
+	Token string
+	//Login - login of the token owner
+	Login string
+	//FewDataThreshold - uploaded segments with fewer records get few_data status when processed
+	FewDataThreshold int
+	//Now - returns the time of created objects
+	Now func() time.Time
+
+	mu       sync.Mutex
+	nextID   int64
+	accounts map[string]*accountState
+	granted  []audience.Account
+	faults   []*faultState
+}
+
+//accountState - objects of one account (the token owner or a delegating account)
+type accountState struct {
+	pixels    map[int64]*pixelState
+	segments  map[int64]*segmentState
+	delegates []*audience.Delegate
+}
+
+type pixelState struct {
+	pixel   audience.Pixel
+	deleted bool
+}
+
+type segmentState struct {
+	segment audience.Segment
+	//records - identifiers of the uploaded segment
+	records map[string]bool
+	grants  []*audience.Grant
+}
+
+//NewServer - starts the server, call Close when it's not needed
+func NewServer() *Server {
+	s := &Server{
+		Token:            DefaultToken,
+		Login:            DefaultLogin,
+		FewDataThreshold: DefaultFewDataThreshold,
+		Now:              time.Now,
+		accounts:         map[string]*accountState{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+//Client - creates a client working with the server
+func (s *Server) Client(opts ...audience.Option) (*audience.Client, error) {
+	opts = append([]audience.Option{audience.WithToken(s.Token), audience.WithBaseURL(s.URL)}, opts...)
+	return audience.NewClient(context.Background(), opts...)
+}
+
+//AddAccount - makes the token owner a representative of the account (see audience.Client.ForAccount)
+func (s *Server) AddAccount(login, perm string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.granted = append(s.granted, audience.Account{UserLogin: login, Perm: perm, CreatedAt: s.Now()})
+}
+
+//SetSegmentStatus - sets status of the segment of the account ("" for the token owner)
+func (s *Server) SetSegmentStatus(login string, segmentID int64, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segment, ok := s.account(login).segments[segmentID]
+	if !ok {
+		return fmt.Errorf("audiencetest: segment %d not found", segmentID)
+	}
+	segment.segment.Base().Status = status
+	return nil
+}
+
+//SegmentStatus - returns status of the segment of the account ("" for the token owner)
+func (s *Server) SegmentStatus(login string, segmentID int64) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segment, ok := s.account(login).segments[segmentID]
+	if !ok {
+		return "", false
+	}
+	return segment.segment.Base().Status, true
+}
+
+//SegmentRecords - returns the number of unique identifiers of the uploaded segment of the account ("" for the token owner)
+func (s *Server) SegmentRecords(login string, segmentID int64) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segment, ok := s.account(login).segments[segmentID]
+	if !ok {
+		return 0, false
+	}
+	return len(segment.records), true
+}
+
+//ProcessSegments - finishes processing of all accounts' segments in is_processed or is_updated status:
+//they become processed or few_data (uploaded segments with fewer records than FewDataThreshold).
+func (s *Server) ProcessSegments() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		for _, segment := range account.segments {
+			base := segment.segment.Base()
+			if base.Status != audience.SegmentStatusIsProcessed && base.Status != audience.SegmentStatusIsUpdated {
+				continue
+			}
+			base.Status = audience.SegmentStatusProcessed
+			if _, ok := segment.segment.(*audience.UploadingSegment); ok && len(segment.records) < s.FewDataThreshold {
+				base.Status = audience.SegmentStatusFewData
+			}
+		}
+	}
+}
+
+//account - returns objects of the account, s.mu must be locked
+func (s *Server) account(login string) *accountState {
+	if login == "" {
+		login = s.Login
+	}
+	account, ok := s.accounts[login]
+	if !ok {
+		account = &accountState{
+			pixels:   map[int64]*pixelState{},
+			segments: map[int64]*segmentState{},
+		}
+		s.accounts[login] = account
+	}
+	return account
+}
+
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+//request - a request to the API
+type request struct {
+	*http.Request
+	//parts - path split by "/" without /v1/management/ prefix
+	parts   []string
+	login   string
+	account *accountState
+	//upload - the file of upload methods, it's read before the server is locked
+	upload *upload
+}
+
+//upload - identifiers of the uploaded file
+type upload struct {
+	filename string
+	records  map[string]bool
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "OAuth "+s.Token {
+		writeError(w, http.StatusUnauthorized, audience.ErrorTypeInvalidToken, "", "Invalid oauth_token")
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/management/")
+	if path == r.URL.Path {
+		writeError(w, http.StatusNotFound, audience.ErrorTypeNotFound, "", "Unknown API version")
+		return
+	}
+	parts := strings.Split(path, "/")
+	if fault, ok := s.nextFault(r.Method, parts); ok {
+		s.serveFault(w, r, parts, fault)
+		return
+	}
+	s.handle(w, r, parts)
+}
+
+//handle - serves API method, the path is split by "/" without /v1/management/ prefix
+//The server is locked while the handler changes the state, uploaded files are read before that.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, parts []string) {
+	login, ok := s.login(r)
+	if !ok {
+		writeError(w, http.StatusForbidden, audience.ErrorTypeAccessDenied, "ulogin", "Access denied")
+		return
+	}
+	req := &request{Request: r, parts: parts, login: login}
+	route, ok := s.route(req)
+	if !ok {
+		writeError(w, http.StatusNotFound, audience.ErrorTypeNotFound, "", "Unknown method")
+		return
+	}
+	if uploadPatterns[route.pattern] {
+		if req.upload, ok = readUpload(w, req); !ok {
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req.account = s.account(login)
+	route.handler(w, req)
+}
+
+//login - returns login of the account the request is made for, false if the token owner doesn't represent it
+func (s *Server) login(r *http.Request) (string, bool) {
+	ulogin := r.URL.Query().Get("ulogin")
+	if ulogin == "" || ulogin == s.Login {
+		return s.Login, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ulogin, s.represents(ulogin)
+}
+
+func (s *Server) represents(login string) bool {
+	for _, account := range s.granted {
+		if account.UserLogin == login {
+			return true
+		}
+	}
+	return false
+}
+
+type handlerFunc func(w http.ResponseWriter, r *request)
+
+type route struct {
+	method  string
+	pattern string
+	handler handlerFunc
+}
+
+//uploadPatterns - routes of the methods receiving a file
+var uploadPatterns = map[string]bool{
+	"segments/upload_file":     true,
+	"segments/upload_csv_file": true,
+	"segment/{id}/modify_data": true,
+}
+
+//route - returns the route of the request
+func (s *Server) route(r *request) (route, bool) {
+	routes := []route{
+		{http.MethodGet, "pixels", s.pixelsList},
+		{http.MethodPost, "pixels", s.createPixel},
+		{http.MethodPut, "pixel/{id}", s.updatePixel},
+		{http.MethodDelete, "pixel/{id}", s.removePixel},
+		{http.MethodPost, "pixel/{id}/undelete", s.undeletePixel},
+		{http.MethodGet, "segments", s.segmentsList},
+		{http.MethodPost, "segments/upload_file", s.uploadSegment},
+		{http.MethodPost, "segments/upload_csv_file", s.uploadSegment},
+		{http.MethodPost, "segments/create_pixel", s.createSegment(func() audience.Segment { return &audience.PixelSegment{} })},
+		{http.MethodPost, "segments/create_lookalike", s.createSegment(func() audience.Segment { return &audience.LookalikeSegment{} })},
+		{http.MethodPost, "segments/create_metrika", s.createSegment(func() audience.Segment { return &audience.MetrikaSegment{} })},
+		{http.MethodPost, "segments/create_appmetrica", s.createSegment(func() audience.Segment { return &audience.AppMetricaSegment{} })},
+		{http.MethodPost, "segments/create_geo", s.createSegment(func() audience.Segment { return &audience.CircleGeoSegment{} })},
+		{http.MethodPost, "segments/create_geo_polygon", s.createSegment(func() audience.Segment { return &audience.PolygonGeoSegment{} })},
+		{http.MethodPost, "segment/{id}/confirm", s.confirmSegment},
+		{http.MethodPost, "segment/{id}/confirm_client_id", s.confirmClientIDSegment},
+		{http.MethodPost, "segment/{id}/modify_data", s.modifySegmentData},
+		{http.MethodPut, "segment/{id}", s.updateSegment},
+		{http.MethodDelete, "segment/{id}", s.removeSegment},
+		{http.MethodPut, "segment/{id}/reprocess", s.reprocessSegment},
+		{http.MethodPost, "segment/{id}/update_geo_points", s.updateGeoPoints},
+		{http.MethodGet, "segment/{id}/grants", s.grantsList},
+		{http.MethodPut, "segment/{id}/grant", s.createGrant},
+		{http.MethodDelete, "segment/{id}/grant", s.removeGrant},
+		{http.MethodGet, "delegates", s.delegatesList},
+		{http.MethodPut, "delegate", s.createDelegate},
+		{http.MethodDelete, "delegate", s.removeDelegate},
+		{http.MethodGet, "accounts", s.accountsList},
+	}
+	for _, route := range routes {
+		if route.method == r.Method && match(route.pattern, r.parts) {
+			return route, true
+		}
+	}
+	return route{}, false
+}
+
+func match(pattern string, parts []string) bool {
+	patternParts := strings.Split(pattern, "/")
+	if len(patternParts) != len(parts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if part != "{id}" && part != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//writeJSON - writes the response with 200 status
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+//writeError - writes API error in the format of the real API
+func writeError(w http.ResponseWriter, statusCode int, errorType, location, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(audience.APIError{
+		Errors:  []audience.Error{{ErrorType: errorType, Message: message, Location: location}},
+		Code:    statusCode,
+		Message: message,
+	})
+}
+
+func writeSuccess(w http.ResponseWriter) {
+	writeJSON(w, map[string]bool{"success": true})
+}
+
+func sortedIDs(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
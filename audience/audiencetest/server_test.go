@@ -0,0 +1,233 @@
+package audiencetest
+
+import (
+	"context"
+	"errors"
+	"github.com/nikon72ru/yandex-audience-api/audience"
+	. "github.com/smartystreets/goconvey/convey"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_Pixels(t *testing.T) {
+	Convey("pixels", t, func() {
+		srv := NewServer()
+		defer srv.Close()
+		client, err := srv.Client()
+		So(err, ShouldBeNil)
+		pixel := &audience.Pixel{Name: "pixel"}
+		So(client.CreatePixel(pixel), ShouldBeNil)
+		So(pixel.ID, ShouldNotEqual, 0)
+		pixel.Name = "renamed"
+		So(client.UpdatePixel(pixel), ShouldBeNil)
+		pixels, err := client.PixelsList()
+		So(err, ShouldBeNil)
+		So(pixels, ShouldHaveLength, 1)
+		So(pixels[0].Name, ShouldEqual, "renamed")
+		So(client.RemovePixel(pixel.ID), ShouldBeNil)
+		pixels, _ = client.PixelsList()
+		So(pixels, ShouldBeEmpty)
+		So(client.UndeletePixel(pixel.ID), ShouldBeNil)
+		pixels, _ = client.PixelsList()
+		So(pixels, ShouldHaveLength, 1)
+		Convey("errors", func() {
+			err := client.RemovePixel(404)
+			So(errors.Is(err, audience.ErrNotFound), ShouldBeTrue)
+			err = client.CreatePixel(&audience.Pixel{})
+			var apiErr *audience.APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.Code, ShouldEqual, http.StatusBadRequest)
+			So(apiErr.Errors[0].Location, ShouldEqual, "name")
+		})
+	})
+}
+
+func TestServer_Segments(t *testing.T) {
+	Convey("segments", t, func() {
+		srv := NewServer()
+		defer srv.Close()
+		client, err := srv.Client()
+		So(err, ShouldBeNil)
+		Convey("uploaded segment lifecycle", func() {
+			segment := &audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "macs"}, ContentType: audience.Mac}
+			So(client.CreateFileSegment(segment, "../../test-files/macs_for_uploads.csv"), ShouldBeNil)
+			status, _ := srv.SegmentStatus("", segment.ID)
+			So(status, ShouldEqual, audience.SegmentStatusUploaded)
+			So(client.SaveUploadedSegment(segment), ShouldBeNil)
+			status, _ = srv.SegmentStatus("", segment.ID)
+			So(status, ShouldEqual, audience.SegmentStatusIsProcessed)
+			So(errors.Is(client.SaveUploadedSegment(segment), audience.ErrInvalidParameter), ShouldBeTrue)
+			srv.ProcessSegments()
+			segments, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			So(segments, ShouldHaveLength, 1)
+			uploaded, ok := segments[0].(*audience.UploadingSegment)
+			So(ok, ShouldBeTrue)
+			So(uploaded.Status, ShouldEqual, audience.SegmentStatusProcessed)
+			So(uploaded.ContentType, ShouldEqual, audience.Mac)
+			So(client.ReprocessSegment(segment.ID), ShouldBeNil)
+			So(errors.Is(client.ReprocessSegment(segment.ID), audience.ErrInvalidParameter), ShouldBeTrue)
+		})
+		Convey("requests during upload", func() {
+			reader, writer := io.Pipe()
+			listed := make(chan error, 1)
+			go func() {
+				_, _ = writer.Write([]byte("B0550841C93B\n"))
+				//the server must serve other requests while the file is uploaded
+				_, err := client.PixelsList()
+				listed <- err
+				_, _ = writer.Write([]byte("600AA52AEC14\n"))
+				_ = writer.Close()
+			}()
+			uploaded := make(chan error, 1)
+			segment := &audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "slow"}}
+			go func() {
+				uploaded <- client.CreateReaderSegment(segment, reader, false)
+			}()
+			select {
+			case err := <-uploaded:
+				So(err, ShouldBeNil)
+			case <-time.After(5 * time.Second):
+				t.Fatal("upload is blocked by the request made during it")
+			}
+			So(<-listed, ShouldBeNil)
+			records, _ := srv.SegmentRecords("", segment.ID)
+			So(records, ShouldEqual, 2)
+		})
+		Convey("modified data", func() {
+			segment := &audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "crm"}, ContentType: audience.Mac}
+			So(client.CreateReaderSegment(segment, strings.NewReader("B0550841C93B\n600AA52AEC14\n"), false), ShouldBeNil)
+			So(errors.Is(client.ModifySegmentData(segment, strings.NewReader("A0550841C93B\n"), audience.ModificationAddition), audience.ErrInvalidParameter), ShouldBeTrue)
+			So(client.SaveUploadedSegment(segment), ShouldBeNil)
+			id := segment.ID
+			So(client.ModifySegmentData(segment, strings.NewReader("A0550841C93B\nB0550841C93B\n"), audience.ModificationAddition), ShouldBeNil)
+			So(segment.ID, ShouldEqual, id)
+			So(segment.Status, ShouldEqual, audience.SegmentStatusIsUpdated)
+			records, _ := srv.SegmentRecords("", id)
+			So(records, ShouldEqual, 3)
+			So(client.ModifySegmentData(segment, strings.NewReader("600AA52AEC14\n"), audience.ModificationSubtraction), ShouldBeNil)
+			records, _ = srv.SegmentRecords("", id)
+			So(records, ShouldEqual, 2)
+			So(client.ModifySegmentData(segment, strings.NewReader("C0550841C93B\n"), audience.ModificationReplace), ShouldBeNil)
+			records, _ = srv.SegmentRecords("", id)
+			So(records, ShouldEqual, 1)
+			srv.ProcessSegments()
+			status, _ := srv.SegmentStatus("", id)
+			So(status, ShouldEqual, audience.SegmentStatusFewData)
+		})
+		Convey("ClientID segment", func() {
+			segment := &audience.ClientIDSegment{UploadingSegment: audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "visitors"}}, CounterID: 44147844}
+			So(client.CreateReaderSegment(&segment.UploadingSegment, strings.NewReader("1548951646284637843\n1548951646284637844\n"), false), ShouldBeNil)
+			So(client.SaveClientIDSegment(segment), ShouldBeNil)
+			So(segment.CounterID, ShouldEqual, 44147844)
+			status, _ := srv.SegmentStatus("", segment.ID)
+			So(status, ShouldEqual, audience.SegmentStatusIsProcessed)
+			So(errors.Is(client.SaveClientIDSegment(segment), audience.ErrInvalidParameter), ShouldBeTrue)
+		})
+		Convey("few data", func() {
+			segment := &audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "few"}, ContentType: audience.Mac}
+			So(client.CreateReaderSegment(segment, strings.NewReader("B0550841C93B\n600AA52AEC14\n"), false), ShouldBeNil)
+			So(client.SaveUploadedSegment(segment), ShouldBeNil)
+			srv.ProcessSegments()
+			status, _ := srv.SegmentStatus("", segment.ID)
+			So(status, ShouldEqual, audience.SegmentStatusFewData)
+		})
+		Convey("geo points", func() {
+			segment := &audience.CircleGeoSegment{BaseSegment: audience.BaseSegment{Name: "stores"}, Radius: 500, Points: []audience.Point{{Latitude: 55.7, Longitude: 37.6}}}
+			So(client.CreateCircleGeoSegment(segment), ShouldBeNil)
+			id := segment.ID
+			segment.Radius = 1000
+			segment.Points = []audience.Point{{Latitude: 59.9, Longitude: 30.3}}
+			So(client.UpdateGeoPoints(segment, audience.ModificationAddition), ShouldBeNil)
+			So(segment.ID, ShouldEqual, id)
+			So(segment.Points, ShouldHaveLength, 2)
+			So(segment.Status, ShouldEqual, audience.SegmentStatusIsUpdated)
+			segment.Points = []audience.Point{{Latitude: 56.8, Longitude: 60.6}}
+			So(client.UpdateGeoPoints(segment, audience.ModificationReplace), ShouldBeNil)
+			So(segment.Points, ShouldResemble, []audience.Point{{Latitude: 56.8, Longitude: 60.6}})
+			srv.ProcessSegments()
+			status, _ := srv.SegmentStatus("", id)
+			So(status, ShouldEqual, audience.SegmentStatusProcessed)
+			err := client.UpdateGeoPoints(&audience.CircleGeoSegment{BaseSegment: audience.BaseSegment{ID: 404}, Radius: 500, Points: segment.Points}, audience.ModificationReplace)
+			So(errors.Is(err, audience.ErrNotFound), ShouldBeTrue)
+		})
+		Convey("segments of all types", func() {
+			pixel := &audience.Pixel{Name: "pixel"}
+			So(client.CreatePixel(pixel), ShouldBeNil)
+			pixelSegment := &audience.PixelSegment{BaseSegment: audience.BaseSegment{Name: "pixel"}, PixelID: int(pixel.ID)}
+			So(client.CreatePixelSegment(pixelSegment), ShouldBeNil)
+			So(client.CreateLookalikeSegment(&audience.LookalikeSegment{BaseSegment: audience.BaseSegment{Name: "lal"}, LookalikeLink: pixelSegment.ID, LookalikeValue: 3}), ShouldBeNil)
+			So(client.CreateMetrikaSegment(&audience.MetrikaSegment{BaseSegment: audience.BaseSegment{Name: "metrika"}, MetrikaSegmentType: "goal_id"}), ShouldBeNil)
+			So(client.CreateAppMetrikaSegment(&audience.AppMetricaSegment{BaseSegment: audience.BaseSegment{Name: "app"}, AppMetricaSegmentType: "api_key"}), ShouldBeNil)
+			So(client.CreateCircleGeoSegment(&audience.CircleGeoSegment{BaseSegment: audience.BaseSegment{Name: "circle"}, Radius: 500, Points: []audience.Point{{Latitude: 55.7, Longitude: 37.6}}}), ShouldBeNil)
+			So(client.CreatePolygonGeoSegment(&audience.PolygonGeoSegment{BaseSegment: audience.BaseSegment{Name: "polygon"}, Polygons: []audience.Points{{Points: []audience.Point{{Latitude: 55.7, Longitude: 37.6}}}}}), ShouldBeNil)
+			segments, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			So(segments, ShouldHaveLength, 6)
+			So(segments[0], ShouldHaveSameTypeAs, &audience.PixelSegment{})
+			So(segments[1], ShouldHaveSameTypeAs, &audience.LookalikeSegment{})
+			So(segments[2], ShouldHaveSameTypeAs, &audience.MetrikaSegment{})
+			So(segments[3], ShouldHaveSameTypeAs, &audience.AppMetricaSegment{})
+			So(segments[4], ShouldHaveSameTypeAs, &audience.CircleGeoSegment{})
+			So(segments[5], ShouldHaveSameTypeAs, &audience.PolygonGeoSegment{})
+			bySegment, err := client.SegmentsList(int(pixel.ID))
+			So(err, ShouldBeNil)
+			So(bySegment, ShouldHaveLength, 1)
+			So(client.UpdateSegment(pixelSegment.ID, map[string]string{"name": "renamed"}), ShouldBeNil)
+			So(client.RemoveSegment(pixelSegment.ID), ShouldBeNil)
+			So(errors.Is(client.RemoveSegment(pixelSegment.ID), audience.ErrNotFound), ShouldBeTrue)
+			err = client.CreateLookalikeSegment(&audience.LookalikeSegment{BaseSegment: audience.BaseSegment{Name: "lal"}, LookalikeLink: 404, LookalikeValue: 3})
+			So(errors.Is(err, audience.ErrInvalidParameter), ShouldBeTrue)
+		})
+	})
+}
+
+func TestServer_Access(t *testing.T) {
+	Convey("grants, delegates and accounts", t, func() {
+		srv := NewServer()
+		defer srv.Close()
+		client, err := srv.Client()
+		So(err, ShouldBeNil)
+		Convey("grants", func() {
+			segment := &audience.CircleGeoSegment{BaseSegment: audience.BaseSegment{Name: "circle"}, Radius: 500, Points: []audience.Point{{Latitude: 55.7, Longitude: 37.6}}}
+			So(client.CreateCircleGeoSegment(segment), ShouldBeNil)
+			So(client.CreateGrant(segment.ID, &audience.Grant{UserLogin: "friend", Comment: "test"}), ShouldBeNil)
+			grants, err := client.GrantsList(segment.ID)
+			So(err, ShouldBeNil)
+			So(grants, ShouldHaveLength, 1)
+			So(client.RemoveGrant(segment.ID, "friend"), ShouldBeNil)
+			So(errors.Is(client.RemoveGrant(segment.ID, "friend"), audience.ErrNotFound), ShouldBeTrue)
+		})
+		Convey("delegates", func() {
+			So(client.CreateDelegate(&audience.Delegate{UserLogin: "manager", Perm: audience.Edit}), ShouldBeNil)
+			delegates, err := client.DelegatesList()
+			So(err, ShouldBeNil)
+			So(delegates, ShouldHaveLength, 1)
+			So(errors.Is(client.CreateDelegate(&audience.Delegate{UserLogin: "manager", Perm: "owner"}), audience.ErrInvalidParameter), ShouldBeTrue)
+			So(client.RemoveDelegate("manager"), ShouldBeNil)
+		})
+		Convey("accounts", func() {
+			srv.AddAccount("agency-client", audience.Edit)
+			accounts, err := client.AccountsList()
+			So(err, ShouldBeNil)
+			So(accounts, ShouldHaveLength, 1)
+			account := client.ForAccount("agency-client")
+			So(account.CreatePixel(&audience.Pixel{Name: "client pixel"}), ShouldBeNil)
+			pixels, _ := client.PixelsList()
+			So(pixels, ShouldBeEmpty)
+			pixels, _ = account.PixelsList()
+			So(pixels, ShouldHaveLength, 1)
+			_, err = client.ForAccount("stranger").PixelsList()
+			So(errors.Is(err, audience.ErrAccessDenied), ShouldBeTrue)
+		})
+		Convey("invalid token", func() {
+			client, err := audience.NewClient(context.Background(), audience.WithToken("wrong"), audience.WithBaseURL(srv.URL))
+			So(err, ShouldBeNil)
+			_, err = client.PixelsList()
+			So(errors.Is(err, audience.ErrAccessDenied), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//MultipartFile - body of Call sent as multipart form with the file, the way segment files are uploaded.
+//The reader is streamed, so the request isn't retried.
+type MultipartFile struct {
+	//Field - name of the file field ("file" if empty)
+	Field string
+	//Filename - name of the file sent in the form
+	Filename string
+	//Reader - content of the file
+	Reader io.Reader
+	//Fields - other fields of the form
+	Fields map[string]string
+}
+
+//Call - calls API method which the client doesn't wrap yet, for example:
+//	var response struct {
+//		Segment audience.BaseSegment `json:"segment"`
+//	}
+//	err := client.Call(ctx, http.MethodPost, "segment/42/new_method", url.Values{"mode": {"fast"}}, request, &response)
+//The path is relative to the management API (https://api-audience.yandex.ru/v1/management/).
+//in is encoded as JSON or sent as multipart form if it's *MultipartFile, nil means no body.
+//The response is decoded into out (if it isn't nil), API errors are returned as *APIError.
+//Token, account, retries, middlewares, logging and tracing work like for other methods.
+func (c *Client) Call(ctx context.Context, method, path string, query url.Values, in, out interface{}) (err error) {
+	ctx, op, err := c.begin(ctx, "Call", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	path = strings.TrimLeft(path, "/")
+	if len(query) > 0 {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		path += separator + query.Encode()
+	}
+	var response rawResponse
+	if file, ok := in.(*MultipartFile); ok {
+		err = c.upload(ctx, op, method, path, file, &response)
+	} else {
+		err = c.call(ctx, method, path, in, &response)
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNoContent && httpErr.Err == nil {
+		//the method has no response body
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if out == nil || len(response.raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(response.raw, out)
+}
+
+//rawResponse - keeps the response body to decode it into the value of any type
+type rawResponse struct {
+	APIError
+	raw json.RawMessage
+}
+
+//UnmarshalJSON - decodes API error and keeps the body
+func (r *rawResponse) UnmarshalJSON(data []byte) error {
+	r.raw = append(r.raw[:0], data...)
+	if len(data) > 0 && data[0] != '{' {
+		return nil
+	}
+	return json.Unmarshal(data, &r.APIError)
+}
+
+//upload - streams the multipart form to API method and decodes the response into out.
+//The size of the uploaded file is saved to the operation.
+func (c *Client) upload(ctx context.Context, op *Operation, method, path string, file *MultipartFile, out apiResponse) error {
+	rp, wp := io.Pipe()
+	//closing the reading side stops the writing goroutine if the upload was interrupted
+	defer c.closer(rp)
+	mpw := multipart.NewWriter(wp)
+	resultChan := make(chan uploadResult, 1)
+	go func() {
+		written, err := file.write(mpw)
+		//closing with error aborts the request instead of sending a truncated file
+		_ = wp.CloseWithError(err)
+		resultChan <- uploadResult{written: written, err: err}
+	}()
+	req := http.Request{
+		Method: method,
+		Header: http.Header{
+			"Content-Type": {mpw.FormDataContentType()},
+		},
+		Body: rp,
+	}
+	resp, err := c.do(ctx, &req, path)
+	if err != nil {
+		return err
+	}
+	defer c.closer(resp.Body)
+	if err := decodeResponse(resp, out); err != nil {
+		return err
+	}
+	//the server has responded, so the writing goroutine mustn't wait for it anymore
+	c.closer(rp)
+	result := <-resultChan
+	op.uploaded = result.written
+	if errors.Is(result.err, io.ErrClosedPipe) {
+		//the server answered before reading the whole file, the pipe was closed by us
+		return nil
+	}
+	return result.err
+}
+
+type uploadResult struct {
+	written int64
+	err     error
+}
+
+//write - writes the fields and the file to multipart form and closes the form.
+//Returns the size of the file.
+func (f *MultipartFile) write(mpw *multipart.Writer) (int64, error) {
+	names := make([]string, 0, len(f.Fields))
+	for name := range f.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := mpw.WriteField(name, f.Fields[name]); err != nil {
+			return 0, err
+		}
+	}
+	field := f.Field
+	if field == "" {
+		field = "file"
+	}
+	part, err := mpw.CreateFormFile(field, f.Filename)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(part, f.Reader)
+	if err != nil {
+		return written, err
+	}
+	return written, mpw.Close()
+}
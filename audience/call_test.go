@@ -0,0 +1,86 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClient_Call(t *testing.T) {
+	Convey("raw call", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/management/segment/42/new_method":
+				var request struct {
+					Value string `json:"value"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&request)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"authorization": r.Header.Get("Authorization"),
+					"content_type":  r.Header.Get("Content-Type"),
+					"mode":          r.URL.Query().Get("mode"),
+					"ulogin":        r.URL.Query().Get("ulogin"),
+					"value":         request.Value,
+				})
+			case "/v1/management/segment/42/upload":
+				file, header, err := r.FormFile("data")
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				content, _ := ioutil.ReadAll(file)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"filename": header.Filename,
+					"content":  string(content),
+					"kind":     r.FormValue("kind"),
+				})
+			case "/v1/management/segment/42/empty":
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[{"error_type":"not_found","message":"Unknown method"}],"code":404,"message":"Unknown method"}`))
+			}
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL))
+		So(err, ShouldBeNil)
+		Convey("JSON body and query", func() {
+			var response map[string]string
+			in := map[string]string{"value": "blah"}
+			err := client.ForAccount("agency").Call(context.Background(), http.MethodPost, "segment/42/new_method", url.Values{"mode": {"fast"}}, in, &response)
+			So(err, ShouldBeNil)
+			So(response, ShouldResemble, map[string]string{
+				"authorization": "OAuth token",
+				"content_type":  "application/json",
+				"mode":          "fast",
+				"ulogin":        "agency",
+				"value":         "blah",
+			})
+		})
+		Convey("multipart body", func() {
+			var response map[string]string
+			file := &MultipartFile{Field: "data", Filename: "data.csv", Reader: strings.NewReader("a\nb"), Fields: map[string]string{"kind": "crm"}}
+			So(client.Call(context.Background(), http.MethodPost, "segment/42/upload", nil, file, &response), ShouldBeNil)
+			So(response, ShouldResemble, map[string]string{"filename": "data.csv", "content": "a\nb", "kind": "crm"})
+		})
+		Convey("no response body", func() {
+			So(client.Call(context.Background(), http.MethodDelete, "segment/42/empty", nil, nil, nil), ShouldBeNil)
+		})
+		Convey("API error", func() {
+			var response map[string]string
+			err := client.Call(context.Background(), http.MethodGet, "/unknown", nil, nil, &response)
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+			var apiErr *APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.Message, ShouldEqual, "Unknown method")
+		})
+	})
+}
@@ -0,0 +1,221 @@
+//Package cassette - record and replay of Yandex Audience API interactions for deterministic tests.
+//
+//Record real traffic once:
+//	rec, _ := cassette.New("testdata/segments.json", cassette.ModeRecord)
+//	defer rec.Stop()
+//	client, _ := audience.NewClient(ctx, audience.WithHTTPClient(rec.Client()))
+//and replay it in CI without network by creating the recorder with ModeReplay.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//Redacted - value of the redacted headers saved to cassette
+const Redacted = "[REDACTED]"
+
+//ErrInteractionNotFound - the replayed request isn't recorded in the cassette (or all its interactions are used)
+var ErrInteractionNotFound = errors.New("cassette: interaction not found")
+
+//Mode - recorder mode
+type Mode int
+
+//Recorder modes
+const (
+	//ModeReplay - responses are taken from the cassette file, network isn't used
+	ModeReplay Mode = iota
+	//ModeRecord - requests are sent to the API and saved to the cassette file by Stop
+	ModeRecord
+	//ModeAuto - replays the cassette if the file exists, records it otherwise
+	ModeAuto
+)
+
+//Request - recorded request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+//Response - recorded response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+//Interaction - a request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+//Cassette - content of the cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+//Recorder - http.RoundTripper recording or replaying interactions. Request bodies are buffered in memory.
+type Recorder struct {
+	//Transport - sends requests in record mode (http.DefaultTransport if nil)
+	Transport http.RoundTripper
+	//RedactHeaders - request headers saved as Redacted (Authorization by default)
+	RedactHeaders []string
+
+	path     string
+	mode     Mode
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+//New - creates a recorder of the cassette file
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		RedactHeaders: []string{"Authorization"},
+		path:          path,
+		mode:          mode,
+	}
+	if mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+//Mode - returns the actual mode of the recorder (ModeAuto is resolved by New)
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+//Client - returns http client for audience.WithHTTPClient option
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+//RoundTrip - implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+//Stop - saves the recorded interactions to the cassette file (does nothing in replay mode)
+func (r *Recorder) Stop() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, data, 0644)
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		//the body isn't compared, but the sender (upload goroutine) has to finish writing it
+		_, _ = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.String() {
+			continue
+		}
+		r.used[i] = true
+		recorded := interaction.Response
+		return &http.Response{
+			StatusCode:    recorded.StatusCode,
+			Status:        recorded.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	header := req.Header.Clone()
+	for _, name := range r.RedactHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, Redacted)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: header,
+			Body:   string(reqBody),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header.Clone(),
+			Body:       string(respBody),
+		},
+	})
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"github.com/nikon72ru/yandex-audience-api/audience"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("record and replay", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/management/segments":
+				_, _ = w.Write([]byte(`{"segments":[{"id":1,"name":"pixel","pixel_id":3}]}`))
+			case "/v1/management/segments/upload_file":
+				_, _ = ioutil.ReadAll(r.Body)
+				_, _ = w.Write([]byte(`{"segment":{"id":2,"name":"file"}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		path := filepath.Join(t.TempDir(), "testdata", "segments.json")
+		rec, err := New(path, ModeAuto)
+		So(err, ShouldBeNil)
+		So(rec.Mode(), ShouldEqual, ModeRecord)
+		client, err := audience.NewClient(context.Background(), audience.WithToken("secret-token"), audience.WithBaseURL(ts.URL), audience.WithHTTPClient(rec.Client()))
+		So(err, ShouldBeNil)
+		segments, err := client.SegmentsList()
+		So(err, ShouldBeNil)
+		So(segments, ShouldHaveLength, 1)
+		So(client.CreateReaderSegment(&audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "file"}}, strings.NewReader("a\nb"), false), ShouldBeNil)
+		So(rec.Stop(), ShouldBeNil)
+		ts.Close()
+
+		data, err := ioutil.ReadFile(path)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldNotContainSubstring, "secret-token")
+		So(string(data), ShouldContainSubstring, Redacted)
+
+		Convey("replay without network", func() {
+			rec, err := New(path, ModeAuto)
+			So(err, ShouldBeNil)
+			So(rec.Mode(), ShouldEqual, ModeReplay)
+			client, err := audience.NewClient(context.Background(), audience.WithToken("other-token"), audience.WithBaseURL(ts.URL), audience.WithHTTPClient(rec.Client()))
+			So(err, ShouldBeNil)
+			segments, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			So(segments, ShouldHaveLength, 1)
+			So(segments[0], ShouldHaveSameTypeAs, &audience.PixelSegment{})
+			segment := &audience.UploadingSegment{BaseSegment: audience.BaseSegment{Name: "file"}}
+			So(client.CreateReaderSegment(segment, strings.NewReader("a\nb"), false), ShouldBeNil)
+			So(segment.ID, ShouldEqual, 2)
+			Convey("each interaction is replayed once", func() {
+				_, err := client.SegmentsList()
+				So(errors.Is(err, ErrInteractionNotFound), ShouldBeTrue)
+			})
+		})
+		Convey("missing cassette", func() {
+			_, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,15 +1,22 @@
 package audience
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Errors section
@@ -19,85 +26,258 @@ var (
 	ErrNotCreated     = errors.New("not created")
 	ErrNotRestored    = errors.New("not restored")
 	ErrNotReprocessed = errors.New("not reprocessed")
+	ErrClientClosed   = errors.New("yandex audience client is closed")
+	ErrCloseTimeout   = errors.New("yandex audience client is closed before running calls finished")
 )
 
 //constants
 const (
 	tokenVariable = "YANDEX_AUDIENCE_TOKEN"
 	apiURL        = "https://api-audience.yandex.ru"
+	apiVersion    = "v1"
+	closeTimeout  = 30 * time.Second
 )
 
-//Client - a client of yandex audience API
+//Client - a client of yandex audience API. It is safe for concurrent use by multiple goroutines.
 type Client struct {
-	token      string
-	apiVersion string
-	apiURL     string
+	tokens      TokenSource
+	apiVersion  string
+	apiURL      string
+	userAgent   string
+	ulogin      string
+	timeout     time.Duration
+	retryPolicy RetryPolicy
+	quota       *QuotaTracker
+	middlewares []Middleware
+	logger      Logger
+	tracer      trace.Tracer
+	metrics     Metrics
+	closeWait   time.Duration
+	state       *clientState
+	//forAccount - the client is returned by ForAccount and shares the state with the root client
+	forAccount bool
 	hc         *http.Client
 }
 
-//NewClient - create a new client to work with API
-func NewClient(ctx context.Context) (*Client, error) {
-	var client Client
-	//Get token from context or env
-	if os.Getenv(tokenVariable) == "" {
-		if tok, ok := ctx.Value(tokenVariable).(string); ok && tok != "" {
-			client.token = tok
-		} else {
+//NewClient - create a new client to work with API.
+//The token is taken from WithTokenSource or WithToken option, then from context value "YANDEX_AUDIENCE_TOKEN",
+//then from environment variable YANDEX_AUDIENCE_TOKEN.
+func NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	client := Client{
+		apiVersion: apiVersion,
+		apiURL:     apiURL,
+		logger:     NopLogger{},
+		closeWait:  closeTimeout,
+		state:      &clientState{idle: make(chan struct{})},
+	}
+	for _, opt := range opts {
+		opt(&client)
+	}
+	if client.tracer == nil {
+		client.tracer = otel.GetTracerProvider().Tracer(TracerName)
+	}
+	if client.tokens == nil {
+		token, ok := ctx.Value(tokenVariable).(string)
+		if !ok || token == "" {
+			token = os.Getenv(tokenVariable)
+		}
+		if token == "" {
 			return nil, ErrTokenIsNotSet
 		}
-	} else {
-		client.token = os.Getenv(tokenVariable)
+		client.tokens = StaticTokenSource(token)
 	}
 	//Creating http client
-	client.hc = &http.Client{}
-	//Set api version: now available only v1
-	client.apiVersion = "v1"
-	//Set api URL
-	client.apiURL = apiURL
+	if client.hc == nil {
+		client.hc = &http.Client{}
+	}
+	if client.timeout > 0 {
+		hc := *client.hc
+		hc.Timeout = client.timeout
+		client.hc = &hc
+	}
 	return &client, nil
 }
 
-//Do - append authorization header with token and call simple http.Client Do method
+//Do - append authorization header with token and call simple http.Client Do method.
+//If API rejects the token (401) and the token source is TokenRefresher, the token is refreshed and the request is sent once again.
 func (c *Client) Do(req *http.Request, path string) (*http.Response, error) {
 	if req.Header == nil {
 		req.Header = http.Header{}
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("OAuth %s", c.token))
+	token, err := c.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", token))
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 	u, err := url.Parse(fmt.Sprintf("%s/%s/management/%s", c.apiURL, c.apiVersion, path))
 	if err != nil {
 		return nil, err
 	}
+	if c.ulogin != "" {
+		query := u.Query()
+		query.Set("ulogin", c.ulogin)
+		u.RawQuery = query.Encode()
+	}
 	req.URL = u
-	return c.hc.Do(req)
+	ctx := req.Context()
+	op, ok := OperationFromContext(ctx)
+	if !ok {
+		//the operation holds its own slot until it's finished
+		if err := c.state.acquire(); err != nil {
+			return nil, err
+		}
+		defer c.state.release()
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	var fields []interface{}
+	if ok {
+		fields = op.fields()
+	}
+	fields = append(fields, "method", req.Method, "path", u.Path)
+	c.logger.Log(ctx, LevelDebug, "audience request started", fields...)
+	start := time.Now()
+	resp, err := c.send(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		resp, err = c.refreshAndResend(req, resp)
+	}
+	latency := time.Since(start)
+	fields = append(fields, "latency", latency)
+	if meta := responseMetaFromContext(ctx); meta != nil && resp != nil {
+		meta.setResponse(resp, latency)
+	}
+	if err != nil {
+		c.logger.Log(ctx, LevelDebug, "audience request failed", append(fields, "error", err.Error())...)
+		return nil, err
+	}
+	if op != nil {
+		op.statusCode = resp.StatusCode
+	}
+	c.logger.Log(ctx, LevelDebug, "audience request finished", append(fields, "status", resp.StatusCode)...)
+	return resp, nil
+}
+
+//ForAccount - returns a client acting on behalf of the account the current user is a representative of
+//(see AccountsList). The login is sent as ulogin parameter with every request.
+//The returned client shares settings and http client with the original one.
+//Close of the returned client does nothing: only the root client (created by NewClient) can be closed,
+//closing it closes the account clients too.
+func (c *Client) ForAccount(login string) *Client {
+	account := *c
+	account.ulogin = login
+	account.forAccount = true
+	return &account
 }
 
-//Close - close the client (all requests after will return errors)
+//Account - returns login of the account the client acts on behalf of (empty for the token owner)
+func (c *Client) Account() string {
+	return c.ulogin
+}
+
+//do - Do with a context
+func (c *Client) do(ctx context.Context, req *http.Request, path string) (*http.Response, error) {
+	return c.Do(req.WithContext(ctx), path)
+}
+
+//call - sends request with JSON encoded body (if in isn't nil) and decodes response into out
+func (c *Client) call(ctx context.Context, method, path string, in interface{}, out apiResponse) error {
+	req := &http.Request{
+		Method: method,
+		Header: http.Header{},
+	}
+	if in != nil {
+		jsonBody, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.ContentLength = int64(len(jsonBody))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(jsonBody)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+	resp, err := c.do(ctx, req, path)
+	if err != nil {
+		return err
+	}
+	defer c.closer(resp.Body)
+	return decodeResponse(resp, out)
+}
+
+//Close - closes the client: waits for the running calls (30 seconds by default, see WithCloseTimeout)
+//and closes idle connections. Calls made after Close return ErrClientClosed.
+//Clients returned by ForAccount are closed too. ErrCloseTimeout is returned if the calls haven't finished in time.
+//Close of a client returned by ForAccount does nothing and returns nil.
 func (c *Client) Close() error {
-	c.hc = nil
-	return nil
+	if c.forAccount || !c.state.close() {
+		return nil
+	}
+	timer := time.NewTimer(c.closeWait)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-c.state.idle:
+	case <-timer.C:
+		//the timer and idle can be ready together (zero timeout)
+		select {
+		case <-c.state.idle:
+		default:
+			err = ErrCloseTimeout
+		}
+	}
+	c.hc.CloseIdleConnections()
+	return err
 }
 
-//Error - format describing return errors
-type Error struct {
-	ErrorType string `json:"error_type"`
-	Message   string `json:"message"`
-	Location  string `json:"location"`
+//clientState - state shared by the client and its ForAccount copies
+type clientState struct {
+	mu      sync.Mutex
+	closed  bool
+	running int
+	//idle - closed when the client is closed and no calls are running
+	idle chan struct{}
 }
 
-//APIError - API returned error
-type APIError struct {
-	Errors  []Error `json:"errors"`
-	Code    int     `json:"code"`
-	Message string  `json:"message"`
+//acquire - registers a running call, call release when it's finished
+func (s *clientState) acquire() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClientClosed
+	}
+	s.running++
+	return nil
 }
 
-func (e *APIError) Error() error {
-	err, _ := json.Marshal(e.Errors)
-	return fmt.Errorf("%d: %s ([%s])", e.Code, e.Message, err)
+func (s *clientState) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	if s.closed && s.running == 0 {
+		close(s.idle)
+	}
+}
+
+//close - marks the client closed, returns false if it's already closed
+func (s *clientState) close() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.closed = true
+	if s.running == 0 {
+		close(s.idle)
+	}
+	return true
 }
 
-func closer(p io.Closer) {
+//closer - closes and logs the error if any
+func (c *Client) closer(p io.Closer) {
 	if err := p.Close(); err != nil {
-		log.Printf("can't close: %s", err.Error())
+		c.logger.Log(context.Background(), LevelWarn, "audience: can't close", "error", err.Error())
 	}
 }
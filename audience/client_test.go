@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -22,7 +23,7 @@ func TestNewClient(t *testing.T) {
 			So(c.apiURL, ShouldEqual, apiURL)
 			So(c.hc, ShouldNotBeNil)
 			So(c.apiVersion, ShouldEqual, "v1")
-			So(c.token, ShouldEqual, token)
+			So(c.tokens, ShouldEqual, StaticTokenSource(token))
 		})
 		Convey("token from envs", func() {
 			_ = os.Setenv(tokenVariable, token)
@@ -31,7 +32,7 @@ func TestNewClient(t *testing.T) {
 			So(c.apiURL, ShouldEqual, apiURL)
 			So(c.hc, ShouldNotBeNil)
 			So(c.apiVersion, ShouldEqual, "v1")
-			So(c.token, ShouldEqual, token)
+			So(c.tokens, ShouldEqual, StaticTokenSource(token))
 		})
 		Convey("token isn't set", func() {
 			c, err := NewClient(context.Background())
@@ -71,18 +72,78 @@ func TestClient_Do(t *testing.T) {
 }
 
 func TestClient_Close(t *testing.T) {
-	_ = os.Setenv(tokenVariable, "blah")
-	cl, err := NewClient(context.Background())
-	if err != nil {
-		t.Fatal(err)
-	}
-	if cl.hc == nil {
-		t.Fatal("client shouldn't be nil")
-	}
-	if err := cl.Close(); err != nil {
-		t.Fatal(err)
-	}
-	if cl.hc != nil {
-		t.Fatal("client should be nil after Close() func")
-	}
+	Convey("close", t, func() {
+		_ = os.Setenv(tokenVariable, "blah")
+		release := make(chan struct{})
+		started := make(chan struct{}, 1)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/management/pixels" {
+				started <- struct{}{}
+				<-release
+			}
+			_, _ = w.Write([]byte(`{"pixels":[],"segments":[]}`))
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithBaseURL(ts.URL), WithCloseTimeout(time.Second))
+		So(err, ShouldBeNil)
+		Convey("calls after close", func() {
+			account := client.ForAccount("agency")
+			So(client.Close(), ShouldBeNil)
+			_, err := client.SegmentsList()
+			So(err, ShouldEqual, ErrClientClosed)
+			So(account.RemoveSegment(1), ShouldEqual, ErrClientClosed)
+			req, _ := http.NewRequest(http.MethodGet, "", nil)
+			_, err = client.Do(req, "segments")
+			So(err, ShouldEqual, ErrClientClosed)
+			So(client.Close(), ShouldBeNil)
+		})
+		Convey("account client isn't closed", func() {
+			account := client.ForAccount("agency")
+			So(account.Close(), ShouldBeNil)
+			So(account.ForAccount("another").Close(), ShouldBeNil)
+			_, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			_, err = account.SegmentsList()
+			So(err, ShouldBeNil)
+		})
+		Convey("waits for running calls", func() {
+			result := make(chan error, 1)
+			go func() {
+				_, err := client.PixelsList()
+				result <- err
+			}()
+			<-started
+			closed := make(chan error, 1)
+			go func() {
+				closed <- client.Close()
+			}()
+			select {
+			case <-closed:
+				t.Fatal("Close shouldn't return before the running call is finished")
+			case <-time.After(50 * time.Millisecond):
+			}
+			close(release)
+			So(<-result, ShouldBeNil)
+			So(<-closed, ShouldBeNil)
+		})
+		Convey("zero timeout without running calls", func() {
+			for i := 0; i < 100; i++ {
+				client, err := NewClient(context.Background(), WithBaseURL(ts.URL), WithCloseTimeout(0))
+				So(err, ShouldBeNil)
+				So(client.Close(), ShouldBeNil)
+			}
+		})
+		Convey("close timeout", func() {
+			client, err := NewClient(context.Background(), WithBaseURL(ts.URL), WithCloseTimeout(10*time.Millisecond))
+			So(err, ShouldBeNil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_, _ = client.PixelsListContext(ctx)
+			}()
+			<-started
+			So(client.Close(), ShouldEqual, ErrCloseTimeout)
+			close(release)
+		})
+	})
 }
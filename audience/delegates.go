@@ -1,10 +1,8 @@
 package audience
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"time"
 )
@@ -19,69 +17,64 @@ type Delegate struct {
 
 //DelegatesList - returns a list of representatives who have been granted access to the current user account.
 func (c *Client) DelegatesList() ([]*Delegate, error) {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodGet,
-	}, "delegates")
+	return c.DelegatesListContext(context.Background())
+}
+
+//DelegatesListContext - DelegatesList with a context.
+func (c *Client) DelegatesListContext(ctx context.Context) (delegates []*Delegate, err error) {
+	ctx, op, err := c.begin(ctx, "DelegatesList", 0)
 	if err != nil {
 		return nil, err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Delegates []*Delegate `json:"delegates"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodGet, "delegates", nil, &response); err != nil {
 		return nil, err
 	}
-	if len(response.Errors) != 0 {
-		return nil, response.Error()
-	}
 	return response.Delegates, nil
 }
 
 //CreateDelegate - adds the user login to the list of representatives for the current account.
 func (c *Client) CreateDelegate(delegate *Delegate) error {
+	return c.CreateDelegateContext(context.Background(), delegate)
+}
+
+//CreateDelegateContext - CreateDelegate with a context.
+func (c *Client) CreateDelegateContext(ctx context.Context, delegate *Delegate) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateDelegate", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
 	requestStruct := struct {
 		Delegate *Delegate `json:"delegate"`
 		APIError
 	}{Delegate: delegate}
-	jsonBody, _ := json.Marshal(requestStruct)
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPut,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, "delegate")
-	if err != nil {
-		return err
-	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
-	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
-	}
-	return nil
+	return c.call(ctx, http.MethodPut, "delegate", &requestStruct, &requestStruct)
 }
 
 //RemoveDelegate - deletes the user login from the list of representatives for the current account.
 func (c *Client) RemoveDelegate(userLogin string) error {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodDelete,
-	}, fmt.Sprintf("delegate?user_login=%s", userLogin))
+	return c.RemoveDelegateContext(context.Background(), userLogin)
+}
+
+//RemoveDelegateContext - RemoveDelegate with a context.
+func (c *Client) RemoveDelegateContext(ctx context.Context, userLogin string) (err error) {
+	ctx, op, err := c.begin(ctx, "RemoveDelegate", 0)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Success bool `json:"success"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodDelete, fmt.Sprintf("delegate?user_login=%s", userLogin), nil, &response); err != nil {
 		return err
 	}
-	if len(response.Errors) != 0 {
-		return response.Error()
-	}
 	if !response.Success {
 		return ErrNotDeleted
 	}
@@ -70,7 +70,7 @@ func TestClient_DelegatesList(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			_, err := client.AccountsList()
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -119,7 +119,7 @@ func TestClient_CreateDelegate(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateDelegate(&Delegate{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -166,7 +166,7 @@ func TestClient_RemoveDelegate(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.RemoveDelegate(login)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("api return false", func(c C) {
 			isServerInvoked := false
@@ -0,0 +1,131 @@
+package audience
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//Error classes of API errors, use errors.Is to check them:
+//	if errors.Is(err, audience.ErrQuotaExceeded) { ... }
+var (
+	ErrInvalidParameter = apiErrorClass("invalid parameter")
+	ErrNotFound         = apiErrorClass("not found")
+	ErrAccessDenied     = apiErrorClass("access denied")
+	ErrQuotaExceeded    = apiErrorClass("quota exceeded")
+	ErrBackendError     = apiErrorClass("backend error")
+)
+
+//Error types returned by API
+const (
+	ErrorTypeInvalidParameter = "invalid_parameter"
+	ErrorTypeNotFound         = "not_found"
+	ErrorTypeAccessDenied     = "access_denied"
+	ErrorTypeInvalidToken     = "invalid_token"
+	ErrorTypeQuotaPrefix      = "quota_"
+	ErrorTypeBackendError     = "backend_error"
+)
+
+type apiErrorClass string
+
+func (e apiErrorClass) Error() string {
+	return string(e)
+}
+
+//Error - format describing return errors
+type Error struct {
+	ErrorType string `json:"error_type"`
+	Message   string `json:"message"`
+	Location  string `json:"location"`
+}
+
+//APIError - API returned error
+type APIError struct {
+	Errors  []Error `json:"errors"`
+	Code    int     `json:"code"`
+	Message string  `json:"message"`
+	//StatusCode - HTTP status of the response
+	StatusCode int `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	details := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		detail := err.ErrorType
+		if err.Location != "" {
+			detail += " at " + err.Location
+		}
+		if err.Message != "" && err.Message != e.Message {
+			detail += ": " + err.Message
+		}
+		details = append(details, detail)
+	}
+	return fmt.Sprintf("%d: %s (%s)", e.Code, e.Message, strings.Join(details, ", "))
+}
+
+//Is - reports whether the error belongs to the class (ErrInvalidParameter, ErrNotFound and so on)
+func (e *APIError) Is(target error) bool {
+	class, ok := target.(apiErrorClass)
+	if !ok {
+		return false
+	}
+	for _, err := range e.Errors {
+		if errorClassByType(err.ErrorType) == class {
+			return true
+		}
+	}
+	if len(e.Errors) == 0 {
+		return errorClassByCode(e.Code) == class || errorClassByCode(e.StatusCode) == class
+	}
+	return false
+}
+
+//ErrorTypes - returns types of all errors in the response
+func (e *APIError) ErrorTypes() []string {
+	types := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		types = append(types, err.ErrorType)
+	}
+	return types
+}
+
+//check - returns the error if API response contains errors
+func (e *APIError) check(resp *http.Response) error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	e.StatusCode = resp.StatusCode
+	return e
+}
+
+func errorClassByType(errorType string) apiErrorClass {
+	switch {
+	case errorType == ErrorTypeInvalidParameter:
+		return ErrInvalidParameter
+	case errorType == ErrorTypeNotFound:
+		return ErrNotFound
+	case errorType == ErrorTypeAccessDenied, errorType == ErrorTypeInvalidToken:
+		return ErrAccessDenied
+	case strings.HasPrefix(errorType, ErrorTypeQuotaPrefix):
+		return ErrQuotaExceeded
+	case errorType == ErrorTypeBackendError:
+		return ErrBackendError
+	}
+	return ""
+}
+
+func errorClassByCode(code int) apiErrorClass {
+	switch {
+	case code == http.StatusBadRequest:
+		return ErrInvalidParameter
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return ErrAccessDenied
+	case code == http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case code >= http.StatusInternalServerError:
+		return ErrBackendError
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	Convey("api error", t, func() {
+		Convey("error message", func() {
+			err := &APIError{
+				Errors: []Error{{
+					ErrorType: "invalid_parameter",
+					Message:   "name is empty",
+					Location:  "segment.name",
+				}, {
+					ErrorType: "backend_error",
+					Message:   "simple error",
+				}},
+				Code:    400,
+				Message: "simple error",
+			}
+			So(err.Error(), ShouldEqual, "400: simple error (invalid_parameter at segment.name: name is empty, backend_error)")
+			So(err.ErrorTypes(), ShouldResemble, []string{"invalid_parameter", "backend_error"})
+		})
+		Convey("error classes", func() {
+			var cases = map[string]error{
+				"invalid_parameter":     ErrInvalidParameter,
+				"not_found":             ErrNotFound,
+				"access_denied":         ErrAccessDenied,
+				"invalid_token":         ErrAccessDenied,
+				"quota_requests_by_uid": ErrQuotaExceeded,
+				"quota_delegates":       ErrQuotaExceeded,
+				"backend_error":         ErrBackendError,
+			}
+			for errorType, class := range cases {
+				var err error = &APIError{Errors: []Error{{ErrorType: errorType}}}
+				So(errors.Is(err, class), ShouldBeTrue)
+				So(errors.Is(err, ErrNotCreated), ShouldBeFalse)
+			}
+			var err error = &APIError{Errors: []Error{{ErrorType: "unknown"}}}
+			So(errors.Is(err, ErrBackendError), ShouldBeFalse)
+		})
+		Convey("error classes by code", func() {
+			So(errors.Is(&APIError{Code: 429}, ErrQuotaExceeded), ShouldBeTrue)
+			So(errors.Is(&APIError{StatusCode: 404}, ErrNotFound), ShouldBeTrue)
+			So(errors.Is(&APIError{Code: 502}, ErrBackendError), ShouldBeTrue)
+			So(errors.Is(&APIError{Code: 200}, ErrBackendError), ShouldBeFalse)
+		})
+	})
+}
+
+func TestClient_APIError(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	client, err := NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	Convey("client returns typed errors", t, func() {
+		var data = APIError{
+			Errors: []Error{{
+				ErrorType: "quota_requests_by_uid",
+				Message:   "quota exceeded",
+				Location:  "uid",
+			}},
+			Code:    429,
+			Message: "quota exceeded",
+		}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(data)
+		}))
+		defer ts.Close()
+		client.hc = ts.Client()
+		client.apiURL = ts.URL
+		_, err := client.PixelsList()
+		So(errors.Is(err, ErrQuotaExceeded), ShouldBeTrue)
+		So(errors.Is(err, ErrInvalidParameter), ShouldBeFalse)
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldBeTrue)
+		So(apiErr.StatusCode, ShouldEqual, http.StatusTooManyRequests)
+		So(apiErr.Code, ShouldEqual, 429)
+		So(apiErr.Errors, ShouldResemble, data.Errors)
+	})
+}
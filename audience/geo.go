@@ -0,0 +1,68 @@
+package audience
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+//Modification types of segment data
+const (
+	ModificationAddition    = "addition"
+	ModificationSubtraction = "subtraction"
+	ModificationReplace     = "replace"
+)
+
+//Limits of circle geo segments
+const (
+	MinGeoRadius = 500
+	MaxGeoRadius = 10000
+)
+
+//UpdateGeoPoints - replaces (ModificationReplace) or appends (ModificationAddition) points of the circle geo segment
+//keeping its ID. Radius and Points of the segment are sent, the segment is updated from the response.
+//Coordinates and radius are checked before calling API, errors.Is(err, ErrInvalidParameter) is true for invalid ones.
+func (c *Client) UpdateGeoPoints(segment *CircleGeoSegment, modificationType string) error {
+	return c.UpdateGeoPointsContext(context.Background(), segment, modificationType)
+}
+
+//UpdateGeoPointsContext - UpdateGeoPoints with a context.
+func (c *Client) UpdateGeoPointsContext(ctx context.Context, segment *CircleGeoSegment, modificationType string) (err error) {
+	ctx, op, err := c.begin(ctx, "UpdateGeoPoints", segment.ID)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	op.setSegment(segment)
+	if modificationType != ModificationAddition && modificationType != ModificationReplace {
+		return fmt.Errorf("%w: modification type %q isn't supported for geo points", ErrInvalidParameter, modificationType)
+	}
+	if err := validateGeoPoints(segment.Radius, segment.Points); err != nil {
+		return err
+	}
+	requestStruct := struct {
+		Segment *CircleGeoSegment `json:"segment"`
+		APIError
+	}{Segment: segment}
+	path := fmt.Sprintf("segment/%d/update_geo_points?modification_type=%s", segment.ID, modificationType)
+	return c.call(ctx, http.MethodPost, path, &requestStruct, &requestStruct)
+}
+
+//validateGeoPoints - checks the radius and coordinates of circle geo segment points
+func validateGeoPoints(radius int, points []Point) error {
+	if radius < MinGeoRadius || radius > MaxGeoRadius {
+		return fmt.Errorf("%w: radius %d is out of [%d, %d]", ErrInvalidParameter, radius, MinGeoRadius, MaxGeoRadius)
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("%w: points can't be empty", ErrInvalidParameter)
+	}
+	for i, point := range points {
+		if point.Latitude < -90 || point.Latitude > 90 {
+			return fmt.Errorf("%w: points[%d]: latitude %v is out of [-90, 90]", ErrInvalidParameter, i, point.Latitude)
+		}
+		if point.Longitude < -180 || point.Longitude > 180 {
+			return fmt.Errorf("%w: points[%d]: longitude %v is out of [-180, 180]", ErrInvalidParameter, i, point.Longitude)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_UpdateGeoPoints(t *testing.T) {
+	Convey("update geo points", t, func() {
+		var requests int32
+		var method, path, query string
+		var request struct {
+			Segment CircleGeoSegment `json:"segment"`
+		}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			method, path, query = r.Method, r.URL.Path, r.URL.Query().Get("modification_type")
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			response := request.Segment
+			response.Status = SegmentStatusIsUpdated
+			response.Points = append([]Point{{Latitude: 55.75, Longitude: 37.62}}, response.Points...)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"segment": response})
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL))
+		So(err, ShouldBeNil)
+		segment := &CircleGeoSegment{BaseSegment: BaseSegment{ID: 15, Name: "stores"}, Radius: 700, Points: []Point{{Latitude: 59.93, Longitude: 30.31, Description: "spb"}}}
+		Convey("addition", func() {
+			So(client.UpdateGeoPoints(segment, ModificationAddition), ShouldBeNil)
+			So(method, ShouldEqual, http.MethodPost)
+			So(path, ShouldEqual, "/v1/management/segment/15/update_geo_points")
+			So(query, ShouldEqual, ModificationAddition)
+			So(request.Segment.Radius, ShouldEqual, 700)
+			So(request.Segment.Points, ShouldHaveLength, 1)
+			So(segment.ID, ShouldEqual, 15)
+			So(segment.Status, ShouldEqual, SegmentStatusIsUpdated)
+			So(segment.Points, ShouldHaveLength, 2)
+		})
+		Convey("invalid values aren't sent", func() {
+			cases := []struct {
+				modificationType string
+				radius           int
+				points           []Point
+			}{
+				{ModificationSubtraction, 700, segment.Points},
+				{ModificationReplace, 499, segment.Points},
+				{ModificationReplace, 10001, segment.Points},
+				{ModificationReplace, 700, nil},
+				{ModificationReplace, 700, []Point{{Latitude: 90.1}}},
+				{ModificationReplace, 700, []Point{{Latitude: 10, Longitude: -180.5}}},
+			}
+			for _, c := range cases {
+				segment.Radius, segment.Points = c.radius, c.points
+				err := client.UpdateGeoPoints(segment, c.modificationType)
+				So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			}
+			So(atomic.LoadInt32(&requests), ShouldEqual, 0)
+		})
+		Convey("bounds are valid", func() {
+			segment.Radius = MaxGeoRadius
+			segment.Points = []Point{{Latitude: -90, Longitude: 180}}
+			So(client.UpdateGeoPoints(segment, ModificationReplace), ShouldBeNil)
+			So(query, ShouldEqual, ModificationReplace)
+		})
+	})
+}
@@ -1,12 +1,9 @@
 package audience
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"net/url"
 	"time"
 )
 
@@ -19,71 +16,64 @@ type Grant struct {
 
 //GrantsList - returns information about segment management permissions.
 func (c *Client) GrantsList(segmentID int64) ([]*Grant, error) {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodGet,
-	}, fmt.Sprintf("segment/%d/grants", segmentID))
+	return c.GrantsListContext(context.Background(), segmentID)
+}
+
+//GrantsListContext - GrantsList with a context.
+func (c *Client) GrantsListContext(ctx context.Context, segmentID int64) (grants []*Grant, err error) {
+	ctx, op, err := c.begin(ctx, "GrantsList", segmentID)
 	if err != nil {
 		return nil, err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Grants []*Grant `json:"grants"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodGet, fmt.Sprintf("segment/%d/grants", segmentID), nil, &response); err != nil {
 		return nil, err
 	}
-	if len(response.Errors) != 0 {
-		return nil, response.Error()
-	}
 	return response.Grants, nil
 }
 
 //CreateGrant - creates permission to manage a segment.
 func (c *Client) CreateGrant(segmentID int64, grant *Grant) error {
+	return c.CreateGrantContext(context.Background(), segmentID, grant)
+}
+
+//CreateGrantContext - CreateGrant with a context.
+func (c *Client) CreateGrantContext(ctx context.Context, segmentID int64, grant *Grant) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateGrant", segmentID)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
 	requestStruct := struct {
 		Grant *Grant `json:"grant"`
 		APIError
 	}{Grant: grant}
-	reqURL, _ := url.Parse(fmt.Sprintf("%s%s/management/segment/%d/grants", apiURL, c.apiVersion, segmentID))
-	jsonBody, _ := json.Marshal(requestStruct)
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPut,
-		URL:    reqURL,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, fmt.Sprintf("segment/%d/grant", segmentID))
-	if err != nil {
-		return err
-	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
-	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
-	}
-	return nil
+	return c.call(ctx, http.MethodPut, fmt.Sprintf("segment/%d/grant", segmentID), &requestStruct, &requestStruct)
 }
 
 //RemoveGrant - removes permission to manage a segment.
 func (c *Client) RemoveGrant(segmentID int64, userLogin string) error {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodDelete,
-	}, fmt.Sprintf("segment/%d/grant?user_login=%s", segmentID, userLogin))
+	return c.RemoveGrantContext(context.Background(), segmentID, userLogin)
+}
+
+//RemoveGrantContext - RemoveGrant with a context.
+func (c *Client) RemoveGrantContext(ctx context.Context, segmentID int64, userLogin string) (err error) {
+	ctx, op, err := c.begin(ctx, "RemoveGrant", segmentID)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Success bool `json:"success"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodDelete, fmt.Sprintf("segment/%d/grant?user_login=%s", segmentID, userLogin), nil, &response); err != nil {
 		return err
 	}
-	if len(response.Errors) != 0 {
-		return response.Error()
-	}
 	if !response.Success {
 		return ErrNotDeleted
 	}
@@ -75,7 +75,7 @@ func TestClient_GrantsList(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			_, err := client.GrantsList(segmentID)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -129,7 +129,7 @@ func TestClient_CreateGrant(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateGrant(segmentID, &Grant{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -178,7 +178,7 @@ func TestClient_RemoveGrant(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.RemoveGrant(segmentID, login)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("api return false", func(c C) {
 			isServerInvoked := false
@@ -0,0 +1,99 @@
+package audience
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+//SegmentService - methods working with segments of all types
+type SegmentService interface {
+	SegmentsList(pixel ...int) ([]Segment, error)
+	SegmentsListContext(ctx context.Context, pixel ...int) ([]Segment, error)
+	CreateFileSegment(segment *UploadingSegment, filename string) error
+	CreateFileSegmentContext(ctx context.Context, segment *UploadingSegment, filename string) error
+	CreateCSVSegment(segment *UploadingSegment, filename string) error
+	CreateCSVSegmentContext(ctx context.Context, segment *UploadingSegment, filename string) error
+	CreateReaderSegment(segment *UploadingSegment, reader io.Reader, isCSV bool) error
+	CreateReaderSegmentContext(ctx context.Context, segment *UploadingSegment, reader io.Reader, isCSV bool) error
+	ModifySegmentData(segment *UploadingSegment, reader io.Reader, modificationType string) error
+	ModifySegmentDataContext(ctx context.Context, segment *UploadingSegment, reader io.Reader, modificationType string) error
+	SaveUploadedSegment(segment *UploadingSegment) error
+	SaveUploadedSegmentContext(ctx context.Context, segment *UploadingSegment) error
+	SaveClientIDSegment(segment *ClientIDSegment) error
+	SaveClientIDSegmentContext(ctx context.Context, segment *ClientIDSegment) error
+	RemoveSegment(id int64) error
+	RemoveSegmentContext(ctx context.Context, id int64) error
+	CreatePixelSegment(segment *PixelSegment) error
+	CreatePixelSegmentContext(ctx context.Context, segment *PixelSegment) error
+	CreateLookalikeSegment(segment *LookalikeSegment) error
+	CreateLookalikeSegmentContext(ctx context.Context, segment *LookalikeSegment) error
+	CreateMetrikaSegment(segment *MetrikaSegment) error
+	CreateMetrikaSegmentContext(ctx context.Context, segment *MetrikaSegment) error
+	CreateAppMetrikaSegment(segment *AppMetricaSegment) error
+	CreateAppMetrikaSegmentContext(ctx context.Context, segment *AppMetricaSegment) error
+	CreateCircleGeoSegment(segment *CircleGeoSegment) error
+	CreateCircleGeoSegmentContext(ctx context.Context, segment *CircleGeoSegment) error
+	CreatePolygonGeoSegment(segment *PolygonGeoSegment) error
+	CreatePolygonGeoSegmentContext(ctx context.Context, segment *PolygonGeoSegment) error
+	UpdateSegment(ID int64, segment interface{}) error
+	UpdateSegmentContext(ctx context.Context, ID int64, segment interface{}) error
+	ReprocessSegment(segmentID int64) error
+	ReprocessSegmentContext(ctx context.Context, segmentID int64) error
+	UpdateGeoPoints(segment *CircleGeoSegment, modificationType string) error
+	UpdateGeoPointsContext(ctx context.Context, segment *CircleGeoSegment, modificationType string) error
+	WaitForSegment(ctx context.Context, id int64, opts WaitOptions) (Segment, error)
+}
+
+//PixelService - methods working with pixels
+type PixelService interface {
+	PixelsList() ([]*Pixel, error)
+	PixelsListContext(ctx context.Context) ([]*Pixel, error)
+	CreatePixel(pixel *Pixel) error
+	CreatePixelContext(ctx context.Context, pixel *Pixel) error
+	RemovePixel(pixelID int64) error
+	RemovePixelContext(ctx context.Context, pixelID int64) error
+	UpdatePixel(pixel *Pixel) error
+	UpdatePixelContext(ctx context.Context, pixel *Pixel) error
+	UndeletePixel(pixelID int64) error
+	UndeletePixelContext(ctx context.Context, pixelID int64) error
+}
+
+//GrantService - methods working with segment access grants
+type GrantService interface {
+	GrantsList(segmentID int64) ([]*Grant, error)
+	GrantsListContext(ctx context.Context, segmentID int64) ([]*Grant, error)
+	CreateGrant(segmentID int64, grant *Grant) error
+	CreateGrantContext(ctx context.Context, segmentID int64, grant *Grant) error
+	RemoveGrant(segmentID int64, userLogin string) error
+	RemoveGrantContext(ctx context.Context, segmentID int64, userLogin string) error
+}
+
+//DelegateService - methods working with account delegates
+type DelegateService interface {
+	DelegatesList() ([]*Delegate, error)
+	DelegatesListContext(ctx context.Context) ([]*Delegate, error)
+	CreateDelegate(delegate *Delegate) error
+	CreateDelegateContext(ctx context.Context, delegate *Delegate) error
+	RemoveDelegate(userLogin string) error
+	RemoveDelegateContext(ctx context.Context, userLogin string) error
+}
+
+//AccountService - methods working with accounts available to the representative
+type AccountService interface {
+	AccountsList() ([]*Account, error)
+	AccountsListContext(ctx context.Context) ([]*Account, error)
+}
+
+//AudienceAPI - all methods of the API client. *Client implements it, audiencemock.Client is a mock for tests.
+type AudienceAPI interface {
+	SegmentService
+	PixelService
+	GrantService
+	DelegateService
+	AccountService
+	Call(ctx context.Context, method, path string, query url.Values, in, out interface{}) error
+	Close() error
+}
+
+var _ AudienceAPI = (*Client)(nil)
@@ -0,0 +1,50 @@
+package audience
+
+import (
+	"context"
+)
+
+//LogLevel - importance of the log event
+type LogLevel int
+
+//Log levels (the values match log/slog levels)
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+//Logger - structured logger. Fields are passed as key-value pairs like in log/slog:
+//	logger.Log(ctx, LevelInfo, "request finished", "status", 200, "latency", time.Second)
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{})
+}
+
+//NopLogger - discards all events (the default logger of the client)
+type NopLogger struct{}
+
+//Log - does nothing
+func (NopLogger) Log(context.Context, LogLevel, string, ...interface{}) {}
+
+//LoggerFunc - function implementing Logger
+type LoggerFunc func(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{})
+
+//Log - calls the function
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	f(ctx, level, msg, keysAndValues...)
+}
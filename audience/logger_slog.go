@@ -0,0 +1,22 @@
+//go:build go1.21
+// +build go1.21
+
+package audience
+
+import (
+	"context"
+	"log/slog"
+)
+
+//SlogLogger - adapts *slog.Logger to Logger
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	l.logger.Log(ctx, slog.Level(level), msg, keysAndValues...)
+}
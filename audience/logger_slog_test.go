@@ -0,0 +1,27 @@
+//go:build go1.21
+// +build go1.21
+
+package audience
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	Convey("slog adapter", t, func() {
+		var buf bytes.Buffer
+		logger := SlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		logger.Log(context.Background(), LevelWarn, "audience operation failed", "operation", "RemoveSegment", "segment_id", 42)
+		var record map[string]interface{}
+		So(json.Unmarshal(buf.Bytes(), &record), ShouldBeNil)
+		So(record["level"], ShouldEqual, "WARN")
+		So(record["msg"], ShouldEqual, "audience operation failed")
+		So(record["operation"], ShouldEqual, "RemoveSegment")
+		So(record["segment_id"], ShouldEqual, 42)
+	})
+}
@@ -0,0 +1,94 @@
+package audience
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type logEvent struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (l *recordingLogger) Log(_ context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	fields := map[string]interface{}{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, logEvent{level: level, msg: msg, fields: fields})
+}
+
+func (l *recordingLogger) find(msg string) (logEvent, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, event := range l.events {
+		if event.msg == msg {
+			return event, true
+		}
+	}
+	return logEvent{}, false
+}
+
+func TestClient_Logger(t *testing.T) {
+	Convey("structured logs", t, func() {
+		logger := &recordingLogger{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/management/segments/upload_file":
+				_, _ = ioutil.ReadAll(r.Body)
+				_ = json.NewEncoder(w).Encode(struct {
+					Segment UploadingSegment `json:"segment"`
+				}{UploadingSegment{BaseSegment: BaseSegment{ID: 12}}})
+			default:
+				_, _ = w.Write([]byte(`{"success":false}`))
+			}
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL), WithLogger(logger))
+		So(err, ShouldBeNil)
+		Convey("request and operation events", func() {
+			So(client.ForAccount("agency").RemoveSegment(42), ShouldEqual, ErrNotDeleted)
+			started, ok := logger.find("audience request started")
+			So(ok, ShouldBeTrue)
+			So(started.level, ShouldEqual, LevelDebug)
+			So(started.fields["operation"], ShouldEqual, "RemoveSegment")
+			So(started.fields["segment_id"], ShouldEqual, 42)
+			So(started.fields["method"], ShouldEqual, http.MethodDelete)
+			finished, ok := logger.find("audience request finished")
+			So(ok, ShouldBeTrue)
+			So(finished.fields["status"], ShouldEqual, http.StatusOK)
+			So(finished.fields["latency"], ShouldNotBeNil)
+			So(finished.fields["account"], ShouldEqual, "agency")
+			failed, ok := logger.find("audience operation failed")
+			So(ok, ShouldBeTrue)
+			So(failed.level, ShouldEqual, LevelWarn)
+			So(failed.fields["error"], ShouldEqual, ErrNotDeleted.Error())
+		})
+		Convey("upload events", func() {
+			segment := UploadingSegment{BaseSegment: BaseSegment{Name: "upload"}}
+			data := bytes.Repeat([]byte("aa:bb:cc:dd:ee:ff\n"), 100)
+			So(client.CreateReaderSegment(&segment, bytes.NewReader(data), false), ShouldBeNil)
+			finished, ok := logger.find("audience operation finished")
+			So(ok, ShouldBeTrue)
+			So(finished.level, ShouldEqual, LevelInfo)
+			So(finished.fields["operation"], ShouldEqual, "CreateReaderSegment")
+			So(finished.fields["segment_id"], ShouldEqual, 12)
+			So(finished.fields["uploaded_bytes"], ShouldEqual, len(data))
+		})
+	})
+}
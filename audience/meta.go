@@ -0,0 +1,95 @@
+package audience
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//Rate limit headers read into ResponseMeta
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+//ResponseMeta - metadata of the last API response of the call. See WithResponseMeta.
+type ResponseMeta struct {
+	//StatusCode, Status - status of the response
+	StatusCode int
+	Status     string
+	//RequestID - Yandex request ID (X-Request-Id header), it's needed for support tickets
+	RequestID string
+	//Header - headers of the response
+	Header http.Header
+	//RateLimit - rate limit headers of the response
+	RateLimit RateLimit
+	//Attempts - number of the sent requests (retries and the request with refreshed token are counted)
+	Attempts int
+	//Latency - time spent on the HTTP requests including retries
+	Latency time.Duration
+	//Duration - time spent by the client method (uploads, quota waiting and so on included)
+	Duration time.Duration
+}
+
+//RateLimit - rate limit headers, the values are -1 (0 for durations) if there is no header
+type RateLimit struct {
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type responseMetaKey struct{}
+
+//WithResponseMeta - returns the context which makes the client method fill the meta:
+//	var meta audience.ResponseMeta
+//	err := client.RemoveSegmentContext(audience.WithResponseMeta(ctx, &meta), id)
+//	log.Printf("request id %s, status %d", meta.RequestID, meta.StatusCode)
+//The meta is filled even if the method returns an error. Don't share it between concurrent calls.
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+func responseMetaFromContext(ctx context.Context) *ResponseMeta {
+	meta, _ := ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	return meta
+}
+
+//setResponse - fills the meta by the response
+func (m *ResponseMeta) setResponse(resp *http.Response, latency time.Duration) {
+	m.StatusCode = resp.StatusCode
+	m.Status = resp.Status
+	m.RequestID = resp.Header.Get(RequestIDHeader)
+	m.Header = resp.Header
+	m.Latency = latency
+	m.Duration = latency
+	m.RateLimit = RateLimit{
+		Limit:     headerInt(resp.Header, RateLimitLimitHeader),
+		Remaining: headerInt(resp.Header, RateLimitRemainingHeader),
+	}
+	if reset := headerInt(resp.Header, RateLimitResetHeader); reset > 0 {
+		m.RateLimit.Reset = time.Duration(reset) * time.Second
+	}
+	m.RateLimit.RetryAfter, _ = retryAfter(resp.Header.Get(RetryAfterHeader), time.Now())
+}
+
+func headerInt(header http.Header, name string) int {
+	value, err := strconv.Atoi(header.Get(name))
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+//countAttempts - counts the requests sent by the doer in the meta of the request context
+func countAttempts(doer Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if meta := responseMetaFromContext(req.Context()); meta != nil {
+			meta.Attempts++
+		}
+		return doer.Do(req)
+	})
+}
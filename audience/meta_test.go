@@ -0,0 +1,69 @@
+package audience
+
+import (
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithResponseMeta(t *testing.T) {
+	Convey("response meta", t, func() {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(RequestIDHeader, "req-42")
+			w.Header().Set(RateLimitLimitHeader, "30")
+			w.Header().Set(RateLimitRemainingHeader, "29")
+			w.Header().Set(RateLimitResetHeader, "60")
+			switch r.URL.Path {
+			case "/v1/management/pixels":
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(`{"pixels":[]}`))
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(RetryAfterHeader, "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"errors":[{"error_type":"quota_requests_by_uid","message":"Too many requests"}],"code":429,"message":"Too many requests"}`))
+			}
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL),
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+		So(err, ShouldBeNil)
+		Convey("successful call", func() {
+			var meta ResponseMeta
+			_, err := client.PixelsListContext(WithResponseMeta(context.Background(), &meta))
+			So(err, ShouldBeNil)
+			So(meta.StatusCode, ShouldEqual, http.StatusOK)
+			So(meta.RequestID, ShouldEqual, "req-42")
+			So(meta.Attempts, ShouldEqual, 2)
+			So(meta.RateLimit, ShouldResemble, RateLimit{Limit: 30, Remaining: 29, Reset: time.Minute})
+			So(meta.Latency, ShouldBeGreaterThan, 0)
+			So(meta.Duration, ShouldBeGreaterThanOrEqualTo, meta.Latency)
+		})
+		Convey("failed call", func() {
+			var meta ResponseMeta
+			err := client.RemoveSegmentContext(WithResponseMeta(context.Background(), &meta), 1)
+			So(err, ShouldNotBeNil)
+			So(meta.StatusCode, ShouldEqual, http.StatusTooManyRequests)
+			So(meta.RequestID, ShouldEqual, "req-42")
+			So(meta.RateLimit.RetryAfter, ShouldEqual, 5*time.Second)
+			So(meta.Attempts, ShouldEqual, 1)
+		})
+		Convey("raw request", func() {
+			var meta ResponseMeta
+			req, _ := http.NewRequest(http.MethodGet, "", nil)
+			resp, err := client.Do(req.WithContext(WithResponseMeta(context.Background(), &meta)), "pixels")
+			So(err, ShouldBeNil)
+			_ = resp.Body.Close()
+			So(meta.StatusCode, ShouldEqual, resp.StatusCode)
+			So(meta.Header.Get(RateLimitLimitHeader), ShouldEqual, "30")
+		})
+	})
+}
@@ -0,0 +1,26 @@
+package audience
+
+import "time"
+
+//OperationStats - result of a client method call reported to Metrics
+type OperationStats struct {
+	//Operation - name of the client method (SegmentsList, CreateCircleGeoSegment and so on)
+	Operation string
+	//StatusCode - HTTP status of the last response (0 if the API hasn't responded)
+	StatusCode int
+	//Duration - time spent by the method including retries
+	Duration time.Duration
+	//UploadedBytes - size of the uploaded file (CreateFileSegment, CreateReaderSegment and so on)
+	UploadedBytes int64
+	//Err - error returned by the method
+	Err error
+}
+
+//Metrics - receives statistics of client calls. See audienceprom package for Prometheus collector.
+//Methods are called concurrently and mustn't block.
+type Metrics interface {
+	//ObserveOperation - called when a client method is finished
+	ObserveOperation(stats OperationStats)
+	//ObserveRetry - called before a failed request is retried, statusCode is 0 for network errors
+	ObserveRetry(operation string, statusCode int)
+}
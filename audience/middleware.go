@@ -0,0 +1,90 @@
+package audience
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Doer - sends HTTP request (*http.Client implements it)
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+//DoerFunc - function implementing Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+//Do - calls the function
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+//Middleware - wraps the next Doer to inspect or change requests and responses.
+//Middlewares are called for every attempt (retries and resending after token refresh included),
+//requests already have Authorization header and full URL.
+type Middleware func(next Doer) Doer
+
+//doer - returns http client wrapped by middlewares (the first middleware is the outermost)
+func (c *Client) doer() Doer {
+	var doer Doer = c.hc
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		doer = c.middlewares[i](doer)
+	}
+	return countAttempts(doer)
+}
+
+//Printer - logger used by LoggingMiddleware (*log.Logger implements it)
+type Printer interface {
+	Printf(format string, v ...interface{})
+}
+
+//LoggingMiddleware - logs every request and response. OAuth token is redacted.
+func LoggingMiddleware(logger Printer) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			logger.Printf("audience: --> %s %s %s", req.Method, req.URL, formatHeader(redactHeader(req.Header)))
+			start := time.Now()
+			resp, err := next.Do(req)
+			if err != nil {
+				logger.Printf("audience: <-- %s %s error: %s (%s)", req.Method, req.URL, err, time.Since(start))
+				return resp, err
+			}
+			logger.Printf("audience: <-- %s %s %s (%s)", req.Method, req.URL, resp.Status, time.Since(start))
+			return resp, nil
+		})
+	}
+}
+
+//UserAgentMiddleware - sets User-Agent header
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("User-Agent", userAgent)
+			return next.Do(req)
+		})
+	}
+}
+
+//redactHeader - returns a copy of the header without secrets
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	if auth := redacted.Get("Authorization"); auth != "" {
+		scheme := strings.SplitN(auth, " ", 2)[0]
+		redacted.Set("Authorization", scheme+" [REDACTED]")
+	}
+	return redacted
+}
+
+func formatHeader(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+strings.Join(header[name], ", "))
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
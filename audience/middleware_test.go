@@ -0,0 +1,65 @@
+package audience
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Middleware(t *testing.T) {
+	Convey("middlewares", t, func(c C) {
+		var userAgent string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.Header.Get("User-Agent")
+			_, _ = w.Write([]byte(`{"pixels":[]}`))
+		}))
+		defer ts.Close()
+		var order []string
+		tracing := func(name string) Middleware {
+			return func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					order = append(order, name)
+					return next.Do(req)
+				})
+			}
+		}
+		Convey("order of middlewares", func() {
+			client, err := NewClient(context.Background(), WithToken("secret-token"), WithBaseURL(ts.URL),
+				WithMiddleware(tracing("first"), tracing("second")), WithMiddleware(tracing("third")))
+			So(err, ShouldBeNil)
+			_, err = client.PixelsList()
+			So(err, ShouldBeNil)
+			So(order, ShouldResemble, []string{"first", "second", "third"})
+		})
+		Convey("logging without token", func() {
+			var buf bytes.Buffer
+			client, err := NewClient(context.Background(), WithToken("secret-token"), WithBaseURL(ts.URL),
+				WithMiddleware(LoggingMiddleware(log.New(&buf, "", 0)), UserAgentMiddleware("tests/1.0")))
+			So(err, ShouldBeNil)
+			_, err = client.PixelsList()
+			So(err, ShouldBeNil)
+			So(userAgent, ShouldEqual, "tests/1.0")
+			So(buf.String(), ShouldContainSubstring, "--> GET "+ts.URL+"/v1/management/pixels")
+			So(buf.String(), ShouldContainSubstring, "Authorization: OAuth [REDACTED]")
+			So(buf.String(), ShouldContainSubstring, "<-- GET "+ts.URL+"/v1/management/pixels 200 OK")
+			So(buf.String(), ShouldNotContainSubstring, "secret-token")
+		})
+		Convey("fault injection", func() {
+			fault := errors.New("injected")
+			client, err := NewClient(context.Background(), WithToken("secret-token"), WithBaseURL(ts.URL),
+				WithMiddleware(func(next Doer) Doer {
+					return DoerFunc(func(req *http.Request) (*http.Response, error) {
+						return nil, fault
+					})
+				}))
+			So(err, ShouldBeNil)
+			_, err = client.PixelsList()
+			So(errors.Is(err, fault), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,103 @@
+package audience
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Operation - a call of the public client method. Middlewares can get it with OperationFromContext.
+type Operation struct {
+	//Name - name of the client method (SegmentsList, CreateCircleGeoSegment and so on)
+	Name string
+	//SegmentID - ID of the segment the method works with (0 if there is no segment or it isn't created yet)
+	SegmentID int64
+	//Account - login of the account the client acts on behalf of
+	Account string
+
+	client      *Client
+	ctx         context.Context
+	span        trace.Span
+	start       time.Time
+	segmentType string
+	statusCode  int
+	uploaded    int64
+}
+
+type operationKey struct{}
+
+//OperationFromContext - returns the operation of the request context
+func OperationFromContext(ctx context.Context) (*Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(*Operation)
+	return op, ok
+}
+
+//begin - starts the operation, call end when it's finished:
+//	ctx, op, err := c.begin(ctx, "RemoveSegment", id)
+//	if err != nil {
+//		return err
+//	}
+//	defer op.end(&err)
+func (c *Client) begin(ctx context.Context, name string, segmentID int64) (context.Context, *Operation, error) {
+	if err := c.state.acquire(); err != nil {
+		return ctx, nil, err
+	}
+	op := &Operation{
+		Name:      name,
+		SegmentID: segmentID,
+		Account:   c.ulogin,
+		client:    c,
+		start:     time.Now(),
+	}
+	ctx, op.span = c.tracer.Start(ctx, "audience."+name, op.spanOptions()...)
+	op.ctx = context.WithValue(ctx, operationKey{}, op)
+	return op.ctx, op, nil
+}
+
+//end - logs and reports the result of the operation, ends its span and lets Close proceed
+func (op *Operation) end(err *error) {
+	defer op.client.state.release()
+	op.endSpan(*err)
+	duration := time.Since(op.start)
+	if meta := responseMetaFromContext(op.ctx); meta != nil {
+		meta.Duration = duration
+	}
+	if op.client.metrics != nil {
+		op.client.metrics.ObserveOperation(OperationStats{
+			Operation:     op.Name,
+			StatusCode:    op.statusCode,
+			Duration:      duration,
+			UploadedBytes: op.uploaded,
+			Err:           *err,
+		})
+	}
+	fields := append(op.fields(), "duration", duration)
+	if op.uploaded > 0 {
+		fields = append(fields, "uploaded_bytes", op.uploaded)
+	}
+	if *err != nil {
+		op.client.logger.Log(op.ctx, LevelWarn, "audience operation failed", append(fields, "error", (*err).Error())...)
+		return
+	}
+	op.client.logger.Log(op.ctx, LevelInfo, "audience operation finished", fields...)
+}
+
+//setSegment - sets ID and type of the segment the operation works with
+func (op *Operation) setSegment(segment Segment) {
+	if op.SegmentID == 0 {
+		op.SegmentID = segment.Base().ID
+	}
+	op.segmentType = segmentType(segment)
+}
+
+func (op *Operation) fields() []interface{} {
+	fields := []interface{}{"operation", op.Name}
+	if op.SegmentID != 0 {
+		fields = append(fields, "segment_id", op.SegmentID)
+	}
+	if op.Account != "" {
+		fields = append(fields, "account", op.Account)
+	}
+	return fields
+}
@@ -0,0 +1,115 @@
+package audience
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Option - configures the client created by NewClient
+type Option func(*Client)
+
+//WithToken - sets OAuth token. It has priority over the token from context and environment.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		if token != "" {
+			c.tokens = StaticTokenSource(token)
+		}
+	}
+}
+
+//WithTokenSource - sets the source asked for a token on every request (token file, OAuth refresh flow and so on).
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = source
+	}
+}
+
+//WithHTTPClient - sets http client used to send requests (custom transport, proxy and so on).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.hc = hc
+	}
+}
+
+//WithBaseURL - sets API URL (default is https://api-audience.yandex.ru).
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.apiURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+//WithAPIVersion - sets API version (default is v1).
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+//WithUserAgent - sets User-Agent header for all requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+//WithTimeout - sets time limit for requests made by the client.
+//The http client passed with WithHTTPClient isn't modified, a copy of it is used.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+//WithRetryPolicy - sets the policy to retry failed requests (no retries by default).
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+//WithQuotaTracker - sets the tracker which guards limited calls (ReprocessSegment) from exceeding API quotas.
+func WithQuotaTracker(tracker *QuotaTracker) Option {
+	return func(c *Client) {
+		c.quota = tracker
+	}
+}
+
+//WithMiddleware - adds middlewares wrapping every HTTP request (logging, metrics, headers and so on).
+//The first middleware is the outermost one.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+//WithLogger - sets structured logger for client events (nothing is logged by default).
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+//WithTracerProvider - sets OpenTelemetry tracer provider for spans of client methods (the global one by default).
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracer = provider.Tracer(TracerName)
+	}
+}
+
+//WithMetrics - sets the receiver of call statistics (requests, latency, uploads, retries and API errors).
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+//WithCloseTimeout - sets how long Close waits for the running calls (30 seconds by default).
+//Zero timeout doesn't wait: Close returns ErrCloseTimeout at once if any call is running.
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.closeWait = timeout
+	}
+}
@@ -0,0 +1,68 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewClient_Options(t *testing.T) {
+	Convey("client options", t, func() {
+		_ = os.Setenv(tokenVariable, "from env")
+		defer func() { _ = os.Setenv(tokenVariable, "") }()
+		Convey("token option has priority", func() {
+			c, err := NewClient(context.WithValue(context.Background(), tokenVariable, "from context"), WithToken("from option"))
+			So(err, ShouldBeNil)
+			So(c.tokens, ShouldEqual, StaticTokenSource("from option"))
+		})
+		Convey("context has priority over env", func() {
+			c, err := NewClient(context.WithValue(context.Background(), tokenVariable, "from context"))
+			So(err, ShouldBeNil)
+			So(c.tokens, ShouldEqual, StaticTokenSource("from context"))
+		})
+		Convey("url, version and http client", func() {
+			hc := &http.Client{}
+			c, err := NewClient(context.Background(),
+				WithBaseURL("http://localhost:8080/"),
+				WithAPIVersion("v2"),
+				WithHTTPClient(hc),
+			)
+			So(err, ShouldBeNil)
+			So(c.apiURL, ShouldEqual, "http://localhost:8080")
+			So(c.apiVersion, ShouldEqual, "v2")
+			So(c.hc, ShouldEqual, hc)
+		})
+		Convey("timeout doesn't modify passed http client", func() {
+			hc := &http.Client{}
+			c, err := NewClient(context.Background(), WithHTTPClient(hc), WithTimeout(time.Second))
+			So(err, ShouldBeNil)
+			So(c.hc.Timeout, ShouldEqual, time.Second)
+			So(hc.Timeout, ShouldEqual, 0)
+		})
+		Convey("user agent", func(c C) {
+			isServerInvoked := false
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				isServerInvoked = true
+				c.So(r.Header.Get("User-Agent"), ShouldEqual, "my-service/1.0")
+				c.So(r.Header.Get("Authorization"), ShouldEqual, "OAuth first")
+				_ = json.NewEncoder(w).Encode(struct{}{})
+			}))
+			defer ts.Close()
+			client, err := NewClient(context.Background(),
+				WithToken("first"),
+				WithBaseURL(ts.URL),
+				WithHTTPClient(ts.Client()),
+				WithUserAgent("my-service/1.0"),
+			)
+			So(err, ShouldBeNil)
+			_, err = client.AccountsList()
+			So(err, ShouldBeNil)
+			So(isServerInvoked, ShouldBeTrue)
+		})
+	})
+}
@@ -1,10 +1,8 @@
 package audience
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"time"
 )
@@ -21,69 +19,64 @@ type Pixel struct {
 
 //PixelsList - returns a list of existing user pixels.
 func (c *Client) PixelsList() ([]*Pixel, error) {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodGet,
-	}, "pixels")
+	return c.PixelsListContext(context.Background())
+}
+
+//PixelsListContext - PixelsList with a context.
+func (c *Client) PixelsListContext(ctx context.Context) (pixels []*Pixel, err error) {
+	ctx, op, err := c.begin(ctx, "PixelsList", 0)
 	if err != nil {
 		return nil, err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Pixels []*Pixel `json:"pixels"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodGet, "pixels", nil, &response); err != nil {
 		return nil, err
 	}
-	if len(response.Errors) != 0 {
-		return nil, response.Error()
-	}
 	return response.Pixels, nil
 }
 
 //CreatePixel - creates a pixel with the specified parameters.
 func (c *Client) CreatePixel(pixel *Pixel) error {
+	return c.CreatePixelContext(context.Background(), pixel)
+}
+
+//CreatePixelContext - CreatePixel with a context.
+func (c *Client) CreatePixelContext(ctx context.Context, pixel *Pixel) (err error) {
+	ctx, op, err := c.begin(ctx, "CreatePixel", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
 	requestStruct := struct {
 		Pixel *Pixel `json:"pixel"`
 		APIError
 	}{Pixel: pixel}
-	jsonBody, _ := json.Marshal(requestStruct)
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPost,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, "pixels")
-	if err != nil {
-		return err
-	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
-	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
-	}
-	return nil
+	return c.call(ctx, http.MethodPost, "pixels", &requestStruct, &requestStruct)
 }
 
 //RemovePixel - deletes the specified pixel.
 func (c *Client) RemovePixel(pixelID int64) error {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodDelete,
-	}, fmt.Sprintf("pixel/%d", pixelID))
+	return c.RemovePixelContext(context.Background(), pixelID)
+}
+
+//RemovePixelContext - RemovePixel with a context.
+func (c *Client) RemovePixelContext(ctx context.Context, pixelID int64) (err error) {
+	ctx, op, err := c.begin(ctx, "RemovePixel", 0)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Success bool `json:"success"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodDelete, fmt.Sprintf("pixel/%d", pixelID), nil, &response); err != nil {
 		return err
 	}
-	if len(response.Errors) != 0 {
-		return response.Error()
-	}
 	if !response.Success {
 		return ErrNotDeleted
 	}
@@ -92,47 +85,42 @@ func (c *Client) RemovePixel(pixelID int64) error {
 
 //UpdatePixel - changes the specified pixel.
 func (c *Client) UpdatePixel(pixel *Pixel) error {
+	return c.UpdatePixelContext(context.Background(), pixel)
+}
+
+//UpdatePixelContext - UpdatePixel with a context.
+func (c *Client) UpdatePixelContext(ctx context.Context, pixel *Pixel) (err error) {
+	ctx, op, err := c.begin(ctx, "UpdatePixel", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
 	requestStruct := struct {
 		Pixel *Pixel `json:"pixel"`
 		APIError
 	}{Pixel: pixel}
-	jsonBody, _ := json.Marshal(requestStruct)
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPut,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, fmt.Sprintf("pixel/%d", pixel.ID))
-	if err != nil {
-		return err
-	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
-	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
-	}
-	return nil
+	return c.call(ctx, http.MethodPut, fmt.Sprintf("pixel/%d", pixel.ID), &requestStruct, &requestStruct)
 }
 
 //UndeletePixel - recovers the deleted pixel.
 func (c *Client) UndeletePixel(pixelID int64) error {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPost,
-	}, fmt.Sprintf("pixel/%d/undelete", pixelID))
+	return c.UndeletePixelContext(context.Background(), pixelID)
+}
+
+//UndeletePixelContext - UndeletePixel with a context.
+func (c *Client) UndeletePixelContext(ctx context.Context, pixelID int64) (err error) {
+	ctx, op, err := c.begin(ctx, "UndeletePixel", 0)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var response struct {
 		Success bool `json:"success"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodPost, fmt.Sprintf("pixel/%d/undelete", pixelID), nil, &response); err != nil {
 		return err
 	}
-	if len(response.Errors) != 0 {
-		return response.Error()
-	}
 	if !response.Success {
 		return ErrNotRestored
 	}
@@ -76,7 +76,7 @@ func TestClient_PixelsList(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			_, err := client.PixelsList()
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -128,7 +128,7 @@ func TestClient_CreatePixel(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreatePixel(&Pixel{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -176,7 +176,7 @@ func TestClient_RemovePixel(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.RemovePixel(pixelID)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("api return false", func(c C) {
 			isServerInvoked := false
@@ -240,7 +240,7 @@ func TestClient_UndeletePixel(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.UndeletePixel(pixelID)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("api return false", func(c C) {
 			isServerInvoked := false
@@ -308,7 +308,7 @@ func TestClient_UpdatePixel(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.UpdatePixel(&Pixel{ID: pixelID})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -0,0 +1,404 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+//Operations with quotas
+const (
+	OperationReprocessSegment = "ReprocessSegment"
+)
+
+//QuotaLimit - limit of operation calls during the window
+type QuotaLimit struct {
+	//PerSegment - calls per segment (0 - unlimited)
+	PerSegment int
+	//PerLogin - calls per user login (0 - unlimited)
+	PerLogin int
+	Window   time.Duration
+}
+
+//DefaultQuotaLimits - documented API quotas
+var DefaultQuotaLimits = map[string]QuotaLimit{
+	OperationReprocessSegment: {PerSegment: 2, PerLogin: 20, Window: 24 * time.Hour},
+}
+
+//quotaLockStale - lock file older than this is left by a crashed process and is removed
+const quotaLockStale = 10 * time.Second
+
+//quotaLockTimeout - the longest wait for the lock file held by another process
+const quotaLockTimeout = 30 * time.Second
+
+//ErrQuotaStoreLocked - the ledger is locked by another process longer than the lock can be waited for
+var ErrQuotaStoreLocked = errors.New("quota store is locked")
+
+//QuotaStore - persistent ledger of limited calls. Keys are built by QuotaTracker.
+//A store implementing only Load and Save must not be shared by several processes.
+type QuotaStore interface {
+	Load() (map[string][]time.Time, error)
+	Save(map[string][]time.Time) error
+}
+
+//QuotaStoreUpdater - QuotaStore which can be shared by several processes (jobs using the same login).
+//QuotaTracker reserves calls through Update, so the limits are checked against calls of all processes.
+type QuotaStoreUpdater interface {
+	QuotaStore
+	//Update - locks the ledger for other processes, loads it, calls change and saves the changed ledger.
+	//The ledger isn't saved if change returns an error, the error is returned.
+	//Waiting for the lock is stopped when the context is done.
+	Update(ctx context.Context, change func(ledger map[string][]time.Time) error) error
+}
+
+//FileQuotaStore - keeps the ledger in a JSON file. The file can be shared by several processes:
+//changes are made under the lock file Path+".lock".
+type FileQuotaStore struct {
+	Path string
+}
+
+//Load - reads the ledger (missing file means empty ledger)
+func (s *FileQuotaStore) Load() (map[string][]time.Time, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return map[string][]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ledger := map[string][]time.Time{}
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+//Save - merges the ledger with the calls saved by other processes and writes it
+func (s *FileQuotaStore) Save(ledger map[string][]time.Time) error {
+	return s.Update(context.Background(), func(saved map[string][]time.Time) error {
+		for key, calls := range ledger {
+			for _, call := range calls {
+				if !containsTime(saved[key], call) {
+					saved[key] = append(saved[key], call)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+//Update - implements QuotaStoreUpdater
+func (s *FileQuotaStore) Update(ctx context.Context, change func(ledger map[string][]time.Time) error) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ledger, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if err := change(ledger); err != nil {
+		return err
+	}
+	return s.write(ledger)
+}
+
+//lock - creates the lock file, waits while another process holds it (quotaLockTimeout at most)
+func (s *FileQuotaStore) lock(ctx context.Context) (func(), error) {
+	path := s.Path + ".lock"
+	timeout := time.NewTimer(quotaLockTimeout)
+	defer timeout.Stop()
+	retry := time.NewTicker(10 * time.Millisecond)
+	defer retry.Stop()
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > quotaLockStale {
+			_ = os.Remove(path)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, fmt.Errorf("%w: %s", ErrQuotaStoreLocked, path)
+		case <-retry.C:
+		}
+	}
+}
+
+//write - writes the ledger to a temporary file and renames it to keep the file consistent
+func (s *FileQuotaStore) write(ledger map[string][]time.Time) error {
+	data, err := json.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+//QuotaError - the call was refused because it would exceed the quota
+type QuotaError struct {
+	Operation string
+	//Scope - "segment" or "login"
+	Scope string
+	Key   string
+	//ResetAt - time when the next call will be available
+	ResetAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota for %s %s is exhausted until %s", e.Operation, e.Scope, e.Key, e.ResetAt.Format(time.RFC3339))
+}
+
+//Is - QuotaError is ErrQuotaExceeded
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+//QuotaTracker - counts limited calls and refuses (or delays) calls which would exceed API quotas.
+//It's safe for concurrent use and can be shared between clients. Processes can share the ledger
+//if its store implements QuotaStoreUpdater (FileQuotaStore does).
+//A call is counted before the request is sent and is given back if the request hasn't reached API
+//(the token can't be got, the context is canceled before sending and so on).
+type QuotaTracker struct {
+	//Wait - delay calls until the quota is available instead of refusing them
+	Wait bool
+
+	mu     sync.Mutex
+	store  QuotaStore
+	limits map[string]QuotaLimit
+	ledger map[string][]time.Time
+	now    func() time.Time
+}
+
+//NewQuotaTracker - creates a tracker with DefaultQuotaLimits. The store is optional (nil keeps the ledger in memory).
+func NewQuotaTracker(store QuotaStore) (*QuotaTracker, error) {
+	t := QuotaTracker{
+		store:  store,
+		limits: map[string]QuotaLimit{},
+		ledger: map[string][]time.Time{},
+		now:    time.Now,
+	}
+	for operation, limit := range DefaultQuotaLimits {
+		t.limits[operation] = limit
+	}
+	if store != nil {
+		ledger, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for key, calls := range ledger {
+			t.ledger[key] = calls
+		}
+	}
+	return &t, nil
+}
+
+//SetLimit - sets the limit of the operation (for example, if API quotas were changed)
+func (t *QuotaTracker) SetLimit(operation string, limit QuotaLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[operation] = limit
+}
+
+//RemainingForSegment - returns how many calls of the operation are available for the segment now
+func (t *QuotaTracker) RemainingForSegment(operation string, segmentID int64) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit := t.limits[operation]
+	t.reload()
+	return t.remaining(segmentKey(operation, segmentID), limit.PerSegment, limit.Window)
+}
+
+//RemainingForLogin - returns how many calls of the operation are available for the user login now.
+//Empty login means the owner of the token.
+func (t *QuotaTracker) RemainingForLogin(operation, login string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit := t.limits[operation]
+	t.reload()
+	return t.remaining(loginKey(operation, login), limit.PerLogin, limit.Window)
+}
+
+//reserve - records the call or returns QuotaError (waits for the quota if Wait is set).
+//The returned function gives the call back if the request hasn't reached API.
+func (t *QuotaTracker) reserve(ctx context.Context, operation string, segmentID int64, login string) (func() error, error) {
+	for {
+		at, err := t.tryReserve(ctx, operation, segmentID, login)
+		if err == nil {
+			return func() error {
+				//the context of the call may be canceled already, the wait for the lock is limited anyway
+				return t.release(context.Background(), operation, segmentID, login, at)
+			}, nil
+		}
+		quotaErr, ok := err.(*QuotaError)
+		if !ok || !t.Wait {
+			return nil, err
+		}
+		timer := time.NewTimer(quotaErr.ResetAt.Sub(t.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+//tryReserve - records the call and returns its time
+func (t *QuotaTracker) tryReserve(ctx context.Context, operation string, segmentID int64, login string) (time.Time, error) {
+	t.mu.Lock()
+	limit, ok := t.limits[operation]
+	t.mu.Unlock()
+	if !ok {
+		return time.Time{}, nil
+	}
+	now := t.now()
+	err := t.update(ctx, func() error {
+		checks := []struct {
+			scope, name, key string
+			max              int
+		}{
+			{"segment", fmt.Sprint(segmentID), segmentKey(operation, segmentID), limit.PerSegment},
+			{"login", login, loginKey(operation, login), limit.PerLogin},
+		}
+		for _, check := range checks {
+			if check.max > 0 && t.remaining(check.key, check.max, limit.Window) == 0 {
+				return &QuotaError{
+					Operation: operation,
+					Scope:     check.scope,
+					Key:       check.name,
+					ResetAt:   t.ledger[check.key][0].Add(limit.Window),
+				}
+			}
+		}
+		for _, check := range checks {
+			if check.max > 0 {
+				t.ledger[check.key] = append(t.ledger[check.key], now)
+			}
+		}
+		return nil
+	})
+	return now, err
+}
+
+//release - removes the call recorded at the time
+func (t *QuotaTracker) release(ctx context.Context, operation string, segmentID int64, login string, at time.Time) error {
+	return t.update(ctx, func() error {
+		for _, key := range []string{segmentKey(operation, segmentID), loginKey(operation, login)} {
+			calls := t.ledger[key]
+			for i, call := range calls {
+				if call.Equal(at) {
+					calls = append(calls[:i:i], calls[i+1:]...)
+					break
+				}
+			}
+			if len(calls) == 0 {
+				delete(t.ledger, key)
+			} else {
+				t.ledger[key] = calls
+			}
+		}
+		return nil
+	})
+}
+
+//update - changes the ledger under t.mu and saves it.
+//The ledger of QuotaStoreUpdater is reloaded and changed under its lock to see calls of other processes,
+//t.mu isn't held while the lock is waited for.
+func (t *QuotaTracker) update(ctx context.Context, change func() error) error {
+	updater, ok := t.store.(QuotaStoreUpdater)
+	if !ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if err := change(); err != nil {
+			return err
+		}
+		if t.store != nil {
+			return t.store.Save(t.ledger)
+		}
+		return nil
+	}
+	return updater.Update(ctx, func(ledger map[string][]time.Time) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.ledger = ledger
+		return change()
+	})
+}
+
+//reload - reads the ledger shared with other processes, t.mu must be locked
+func (t *QuotaTracker) reload() {
+	if _, ok := t.store.(QuotaStoreUpdater); !ok {
+		return
+	}
+	if ledger, err := t.store.Load(); err == nil {
+		t.ledger = ledger
+	}
+}
+
+//remaining - drops expired calls and returns the rest of the quota
+func (t *QuotaTracker) remaining(key string, max int, window time.Duration) int {
+	if max <= 0 {
+		return -1
+	}
+	calls := t.ledger[key]
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Before(calls[j]) })
+	from := t.now().Add(-window)
+	for len(calls) > 0 && !calls[0].After(from) {
+		calls = calls[1:]
+	}
+	if len(calls) == 0 {
+		delete(t.ledger, key)
+	} else {
+		t.ledger[key] = calls
+	}
+	if len(calls) >= max {
+		return 0
+	}
+	return max - len(calls)
+}
+
+func segmentKey(operation string, segmentID int64) string {
+	return fmt.Sprintf("%s/segment/%d", operation, segmentID)
+}
+
+func loginKey(operation, login string) string {
+	return fmt.Sprintf("%s/login/%s", operation, login)
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, tm := range times {
+		if tm.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
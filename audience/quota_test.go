@@ -0,0 +1,189 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func reserveErr(_ func() error, err error) error {
+	return err
+}
+
+func TestQuotaTracker(t *testing.T) {
+	Convey("quota tracker", t, func() {
+		tracker, err := NewQuotaTracker(nil)
+		So(err, ShouldBeNil)
+		ctx := context.Background()
+		Convey("per segment quota", func() {
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 2)
+			So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, "")), ShouldBeNil)
+			So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, "")), ShouldBeNil)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 0)
+			So(tracker.RemainingForLogin(OperationReprocessSegment, ""), ShouldEqual, 18)
+			err := reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, ""))
+			var quotaErr *QuotaError
+			So(errors.As(err, &quotaErr), ShouldBeTrue)
+			So(quotaErr.Scope, ShouldEqual, "segment")
+			So(quotaErr.ResetAt, ShouldHappenAfter, time.Now().Add(23*time.Hour))
+			So(errors.Is(err, ErrQuotaExceeded), ShouldBeTrue)
+			//refused call isn't counted
+			So(tracker.RemainingForLogin(OperationReprocessSegment, ""), ShouldEqual, 18)
+		})
+		Convey("per login quota", func() {
+			for i := int64(0); i < 20; i++ {
+				So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, i, "agency")), ShouldBeNil)
+			}
+			err := reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 100, "agency"))
+			var quotaErr *QuotaError
+			So(errors.As(err, &quotaErr), ShouldBeTrue)
+			So(quotaErr.Scope, ShouldEqual, "login")
+			So(quotaErr.Key, ShouldEqual, "agency")
+			So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 100, "another")), ShouldBeNil)
+		})
+		Convey("expired calls are forgotten", func() {
+			now := time.Now()
+			tracker.now = func() time.Time { return now }
+			So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, "")), ShouldBeNil)
+			now = now.Add(24*time.Hour + time.Second)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 2)
+		})
+		Convey("wait for quota", func() {
+			tracker.Wait = true
+			tracker.SetLimit(OperationReprocessSegment, QuotaLimit{PerSegment: 1, Window: 50 * time.Millisecond})
+			start := time.Now()
+			So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, "")), ShouldBeNil)
+			So(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, "")), ShouldBeNil)
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 50*time.Millisecond)
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			So(errors.Is(reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 1, "")), context.DeadlineExceeded), ShouldBeTrue)
+		})
+	})
+}
+
+func TestFileQuotaStore(t *testing.T) {
+	Convey("file quota store", t, func() {
+		dir, err := ioutil.TempDir("", "quota")
+		So(err, ShouldBeNil)
+		defer func() { _ = os.RemoveAll(dir) }()
+		store := &FileQuotaStore{Path: filepath.Join(dir, "ledger.json")}
+		tracker, err := NewQuotaTracker(store)
+		So(err, ShouldBeNil)
+		So(reserveErr(tracker.reserve(context.Background(), OperationReprocessSegment, 1, "")), ShouldBeNil)
+		restored, err := NewQuotaTracker(store)
+		So(err, ShouldBeNil)
+		So(restored.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 1)
+		So(restored.RemainingForLogin(OperationReprocessSegment, ""), ShouldEqual, 19)
+		Convey("shared by processes", func() {
+			another, err := NewQuotaTracker(store)
+			So(err, ShouldBeNil)
+			So(reserveErr(another.reserve(context.Background(), OperationReprocessSegment, 1, "")), ShouldBeNil)
+			err = reserveErr(tracker.reserve(context.Background(), OperationReprocessSegment, 1, ""))
+			So(errors.Is(err, ErrQuotaExceeded), ShouldBeTrue)
+			So(reserveErr(tracker.reserve(context.Background(), OperationReprocessSegment, 2, "")), ShouldBeNil)
+			So(another.RemainingForLogin(OperationReprocessSegment, ""), ShouldEqual, 17)
+			ledger, err := store.Load()
+			So(err, ShouldBeNil)
+			So(ledger[loginKey(OperationReprocessSegment, "")], ShouldHaveLength, 3)
+		})
+		Convey("save merges the ledgers", func() {
+			at := time.Now()
+			So(store.Save(map[string][]time.Time{"a": {at}}), ShouldBeNil)
+			So(store.Save(map[string][]time.Time{"a": {at}, "b": {at}}), ShouldBeNil)
+			ledger, err := store.Load()
+			So(err, ShouldBeNil)
+			So(ledger["a"], ShouldHaveLength, 1)
+			So(ledger["b"], ShouldHaveLength, 1)
+			So(ledger[segmentKey(OperationReprocessSegment, 1)], ShouldHaveLength, 1)
+		})
+		Convey("released call", func() {
+			release, err := tracker.reserve(context.Background(), OperationReprocessSegment, 1, "")
+			So(err, ShouldBeNil)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 0)
+			So(release(), ShouldBeNil)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 1)
+			restored, err := NewQuotaTracker(store)
+			So(err, ShouldBeNil)
+			So(restored.RemainingForLogin(OperationReprocessSegment, ""), ShouldEqual, 19)
+		})
+		Convey("stale lock file", func() {
+			lock := store.Path + ".lock"
+			So(ioutil.WriteFile(lock, nil, 0600), ShouldBeNil)
+			old := time.Now().Add(-time.Minute)
+			So(os.Chtimes(lock, old, old), ShouldBeNil)
+			So(reserveErr(tracker.reserve(context.Background(), OperationReprocessSegment, 3, "")), ShouldBeNil)
+			_, err := os.Stat(lock)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("lock held by another process", func() {
+			lock := store.Path + ".lock"
+			So(ioutil.WriteFile(lock, nil, 0600), ShouldBeNil)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			done := make(chan error)
+			go func() {
+				done <- reserveErr(tracker.reserve(ctx, OperationReprocessSegment, 3, ""))
+			}()
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 1), ShouldEqual, 1)
+			So(errors.Is(<-done, context.DeadlineExceeded), ShouldBeTrue)
+			So(os.Remove(lock), ShouldBeNil)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 3), ShouldEqual, 2)
+		})
+		Convey("broken ledger", func() {
+			So(ioutil.WriteFile(store.Path, []byte("{"), 0600), ShouldBeNil)
+			_, err := NewQuotaTracker(store)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestClient_ReprocessSegmentQuota(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	Convey("reprocess segment with quota tracker", t, func() {
+		tracker, _ := NewQuotaTracker(nil)
+		client, err := NewClient(context.Background(), WithQuotaTracker(tracker))
+		So(err, ShouldBeNil)
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			_ = json.NewEncoder(w).Encode(struct {
+				Success bool `json:"success"`
+			}{true})
+		}))
+		defer ts.Close()
+		client.hc = ts.Client()
+		client.apiURL = ts.URL
+		So(client.ReprocessSegment(1), ShouldBeNil)
+		So(client.ReprocessSegment(1), ShouldBeNil)
+		So(errors.Is(client.ReprocessSegment(1), ErrQuotaExceeded), ShouldBeTrue)
+		So(calls, ShouldEqual, 2)
+		Convey("calls which haven't reached API are given back", func() {
+			noToken, err := NewClient(context.Background(), WithTokenSource(StaticTokenSource("")), WithBaseURL(ts.URL), WithQuotaTracker(tracker))
+			So(err, ShouldBeNil)
+			So(errors.Is(noToken.ReprocessSegment(2), ErrTokenIsNotSet), ShouldBeTrue)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 2), ShouldEqual, 2)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			So(errors.Is(client.ReprocessSegmentContext(ctx, 2), context.Canceled), ShouldBeTrue)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 2), ShouldEqual, 2)
+			So(tracker.RemainingForLogin(OperationReprocessSegment, ""), ShouldEqual, 18)
+			So(calls, ShouldEqual, 2)
+		})
+		Convey("calls answered by API are counted", func() {
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+			So(client.ReprocessSegment(3), ShouldNotBeNil)
+			So(tracker.RemainingForSegment(OperationReprocessSegment, 3), ShouldEqual, 1)
+		})
+	})
+}
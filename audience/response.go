@@ -0,0 +1,124 @@
+package audience
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+//constants
+const (
+	//RequestIDHeader - header with ID of the request, it's useful for support tickets
+	RequestIDHeader = "X-Request-Id"
+	//maxBodySnippet - how many bytes of unexpected response body are kept in HTTPError
+	maxBodySnippet = 512
+)
+
+//HTTPError - unexpected HTTP response: not a JSON, empty body or non-2xx status without API error description
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	RequestID  string
+	//Body - the beginning of the response body
+	Body string
+	//Err - the reason of decoding failure (if any)
+	Err error
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("unexpected response: %s", e.Status)
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %s)", e.RequestID)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Body != "" {
+		msg += fmt.Sprintf(": %q", e.Body)
+	}
+	return msg
+}
+
+//Unwrap - returns the decoding error
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+//Is - reports whether the error belongs to the class by HTTP status (ErrBackendError for 5xx and so on)
+func (e *HTTPError) Is(target error) bool {
+	class, ok := target.(apiErrorClass)
+	return ok && errorClassByCode(e.StatusCode) == class
+}
+
+//apiResponse - response struct with embedded APIError
+type apiResponse interface {
+	apiError() *APIError
+}
+
+func (e *APIError) apiError() *APIError {
+	return e
+}
+
+//decodeResponse - checks status code and content type of the response and decodes its body into out.
+//Returns *APIError if API described the error and *HTTPError if the response is unexpected.
+func decodeResponse(resp *http.Response, out apiResponse) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return newHTTPError(resp, body, err)
+	}
+	if !isJSON(resp.Header.Get("Content-Type"), body) {
+		return newHTTPError(resp, body, nil)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return newHTTPError(resp, body, err)
+	}
+	apiErr := out.apiError()
+	if err := apiErr.check(resp); err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if apiErr.Code != 0 || apiErr.Message != "" {
+			apiErr.StatusCode = resp.StatusCode
+			return apiErr
+		}
+		return newHTTPError(resp, body, nil)
+	}
+	return nil
+}
+
+//isJSON - reports whether the body should be decoded as JSON.
+//Some servers don't set content type, so the body itself is checked in that case.
+func isJSON(contentType string, body []byte) bool {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return false
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if strings.HasSuffix(mediaType, "json") {
+			return true
+		}
+		if mediaType != "text/plain" && mediaType != "application/octet-stream" {
+			return false
+		}
+	}
+	return body[0] == '{' || body[0] == '['
+}
+
+func newHTTPError(resp *http.Response, body []byte, err error) *HTTPError {
+	if len(body) > maxBodySnippet {
+		body = body[:maxBodySnippet]
+	}
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		RequestID:  resp.Header.Get(RequestIDHeader),
+		Body:       string(body),
+		Err:        err,
+	}
+}
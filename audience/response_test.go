@@ -0,0 +1,89 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestClient_UnexpectedResponses(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	client, err := NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(handler http.HandlerFunc) func() {
+		ts := httptest.NewServer(handler)
+		client.hc = ts.Client()
+		client.apiURL = ts.URL
+		return ts.Close
+	}
+	Convey("unexpected responses", t, func() {
+		Convey("html from proxy", func() {
+			defer serve(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Header().Set(RequestIDHeader, "req-1")
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte("<html><body>502 Bad Gateway" + strings.Repeat(" ", 1000) + "</body></html>"))
+			})()
+			_, err := client.SegmentsList()
+			var httpErr *HTTPError
+			So(errors.As(err, &httpErr), ShouldBeTrue)
+			So(httpErr.StatusCode, ShouldEqual, http.StatusBadGateway)
+			So(httpErr.RequestID, ShouldEqual, "req-1")
+			So(httpErr.Header.Get("Content-Type"), ShouldEqual, "text/html")
+			So(httpErr.Body, ShouldStartWith, "<html><body>502 Bad Gateway")
+			So(len(httpErr.Body), ShouldEqual, maxBodySnippet)
+			So(errors.Is(err, ErrBackendError), ShouldBeTrue)
+		})
+		Convey("empty response", func() {
+			defer serve(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})()
+			err := client.RemoveSegment(1)
+			var httpErr *HTTPError
+			So(errors.As(err, &httpErr), ShouldBeTrue)
+			So(httpErr.StatusCode, ShouldEqual, http.StatusNoContent)
+		})
+		Convey("truncated json", func() {
+			defer serve(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"pixels":[{"id":1`))
+			})()
+			_, err := client.PixelsList()
+			var httpErr *HTTPError
+			So(errors.As(err, &httpErr), ShouldBeTrue)
+			So(httpErr.StatusCode, ShouldEqual, http.StatusOK)
+			So(httpErr.Err, ShouldNotBeNil)
+			So(httpErr.Body, ShouldEqual, `{"pixels":[{"id":1`)
+		})
+		Convey("json error without details", func() {
+			defer serve(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"code":404,"message":"segment not found"}`))
+			})()
+			err := client.ReprocessSegment(1)
+			var apiErr *APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.StatusCode, ShouldEqual, http.StatusNotFound)
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+		})
+		Convey("empty json object with error status", func() {
+			defer serve(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{}`))
+			})()
+			_, err := client.AccountsList()
+			var httpErr *HTTPError
+			So(errors.As(err, &httpErr), ShouldBeTrue)
+			So(httpErr.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+		})
+	})
+}
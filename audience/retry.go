@@ -0,0 +1,179 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RetryPolicy - describes how failed requests are retried.
+//Requests are retried after network errors and 429, 500, 502, 503, 504 responses.
+//Only idempotent requests (GET) and requests with context marked by MarkRetryable are retried.
+type RetryPolicy struct {
+	//MaxAttempts - total number of attempts including the first one (0 or 1 disables retries)
+	MaxAttempts int
+	//MinBackoff - delay before the first retry, it's doubled for every next retry
+	MinBackoff time.Duration
+	//MaxBackoff - maximum delay between attempts, Retry-After header is limited by it too
+	MaxBackoff time.Duration
+	//Jitter - part of the delay which is randomized, from 0 to 1
+	Jitter float64
+}
+
+//DefaultRetryPolicy - reasonable retry policy for background jobs
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+	Jitter:      0.2,
+}
+
+type retryableKey struct{}
+
+//MarkRetryable - marks the context so that mutation requests made with it are retried by the retry policy.
+//Use it for calls which are safe to repeat (UpdateSegment, RemoveGrant and so on).
+func MarkRetryable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableKey{}, true)
+}
+
+//canResend - reports whether the request body can be sent once again (streaming body can't)
+func canResend(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+//cloneRequest - returns a copy of the request with a fresh body
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+//drain - reads the rest of the body (to reuse the connection) and closes it
+func (c *Client) drain(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxBodySnippet))
+	c.closer(resp.Body)
+}
+
+func isRetryable(req *http.Request) bool {
+	if !canResend(req) {
+		return false
+	}
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	marked, _ := req.Context().Value(retryableKey{}).(bool)
+	return marked
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+//backoff - returns delay before the next attempt (attempt starts from 1)
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+				delay = p.MaxBackoff
+			}
+			return delay
+		}
+	}
+	delay := p.MinBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		jitter := time.Duration(float64(delay) * p.Jitter)
+		delay = delay - jitter + time.Duration(rand.Int63n(int64(2*jitter)+1))
+	}
+	return delay
+}
+
+//retryAfter - parses Retry-After header value: delay in seconds or HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+//send - sends the request retrying it according to the retry policy
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	doer := c.doer()
+	policy := c.retryPolicy
+	if policy.MaxAttempts <= 1 || !isRetryable(req) {
+		return doer.Do(req)
+	}
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = cloneRequest(req); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := doer.Do(attemptReq)
+		if attempt >= policy.MaxAttempts || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		delay := policy.backoff(attempt, resp)
+		fields := []interface{}{"method", req.Method, "path", req.URL.Path, "attempt", attempt, "delay", delay}
+		var operation string
+		if op, ok := OperationFromContext(ctx); ok {
+			operation = op.Name
+			fields = append(op.fields(), fields...)
+		}
+		var statusCode int
+		if resp != nil {
+			statusCode = resp.StatusCode
+			fields = append(fields, "status", resp.StatusCode)
+			c.drain(resp)
+		} else {
+			fields = append(fields, "error", err.Error())
+		}
+		if c.metrics != nil {
+			c.metrics.ObserveRetry(operation, statusCode)
+		}
+		c.logger.Log(ctx, LevelInfo, "audience request will be retried", fields...)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
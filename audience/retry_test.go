@@ -0,0 +1,109 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Retry(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	client, err := NewClient(context.Background(), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Convey("retries", t, func() {
+		var calls int32
+		failures := int32(2)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) <= failures {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"success":true,"pixels":[{"id":1}]}`))
+		}))
+		defer ts.Close()
+		client.hc = ts.Client()
+		client.apiURL = ts.URL
+		Convey("idempotent request is retried", func() {
+			start := time.Now()
+			pixels, err := client.PixelsList()
+			So(err, ShouldBeNil)
+			So(len(pixels), ShouldEqual, 1)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+			//Retry-After is limited by MaxBackoff
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+		Convey("attempts are limited", func() {
+			failures = 5
+			_, err := client.PixelsList()
+			So(errors.Is(err, ErrBackendError), ShouldBeTrue)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+		})
+		Convey("mutation isn't retried by default", func() {
+			err := client.UndeletePixel(1)
+			So(errors.Is(err, ErrBackendError), ShouldBeTrue)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+		})
+		Convey("marked mutation is retried with the same body", func(c C) {
+			var bodies []string
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body [64]byte
+				n, _ := r.Body.Read(body[:])
+				bodies = append(bodies, string(body[:n]))
+				if atomic.AddInt32(&calls, 1) <= failures {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				_, _ = w.Write([]byte(`{"pixel":{"id":1,"name":"renamed"}}`))
+			})
+			err := client.UpdatePixelContext(MarkRetryable(context.Background()), &Pixel{ID: 1, Name: "renamed"})
+			So(err, ShouldBeNil)
+			So(len(bodies), ShouldEqual, 3)
+			So(bodies[2], ShouldEqual, bodies[0])
+			So(bodies[0], ShouldContainSubstring, "renamed")
+		})
+		Convey("context cancels waiting", func() {
+			client.retryPolicy.MinBackoff = time.Hour
+			client.retryPolicy.MaxBackoff = time.Hour
+			defer func() { client.retryPolicy = policy }()
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := client.PixelsListContext(ctx)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+	})
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	Convey("backoff", t, func() {
+		p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+		So(p.backoff(1, nil), ShouldEqual, 100*time.Millisecond)
+		So(p.backoff(2, nil), ShouldEqual, 200*time.Millisecond)
+		So(p.backoff(10, nil), ShouldEqual, time.Second)
+		p.Jitter = 0.5
+		for i := 0; i < 100; i++ {
+			delay := p.backoff(1, nil)
+			So(delay, ShouldBeBetweenOrEqual, 50*time.Millisecond, 150*time.Millisecond)
+		}
+	})
+	Convey("retry after", t, func() {
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		delay, ok := retryAfter("3", now)
+		So(ok, ShouldBeTrue)
+		So(delay, ShouldEqual, 3*time.Second)
+		delay, ok = retryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+		So(ok, ShouldBeTrue)
+		So(delay, ShouldEqual, time.Minute)
+		_, ok = retryAfter("soon", now)
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -1,14 +1,14 @@
 package audience
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,6 +32,22 @@ type BaseSegment struct {
 	Owner      string    `json:"owner"`
 }
 
+//Segment - a segment of any type. Use a type switch to get the concrete segment.
+type Segment interface {
+	Base() *BaseSegment
+}
+
+//Base - returns the fields common to all segment types.
+func (s *BaseSegment) Base() *BaseSegment {
+	return s
+}
+
+//UnknownSegment - a segment of a type this package can't recognize. Raw keeps the original JSON.
+type UnknownSegment struct {
+	BaseSegment
+	Raw json.RawMessage `json:"-"`
+}
+
 //PixelSegment - a segment created by pixel.
 type PixelSegment struct {
 	BaseSegment
@@ -91,6 +107,12 @@ type UploadingSegment struct {
 	ContentType string `json:"content_type"`
 }
 
+//ClientIDSegment - an uploaded segment of Yandex.Metrica ClientIDs collected by the counter.
+type ClientIDSegment struct {
+	UploadingSegment
+	CounterID int `json:"counter_id"`
+}
+
 //LookalikeSegment - a segment from users who are “similar” to another segment of the client (Look-alike technology).
 type LookalikeSegment struct {
 	BaseSegment
@@ -108,156 +130,219 @@ type MetrikaSegment struct {
 }
 
 //SegmentsList - returns a list of existing segments available to the user.
-func (c *Client) SegmentsList(pixel ...int) ([]map[string]interface{}, error) {
+//Every segment is decoded into its concrete type: *PixelSegment, *LookalikeSegment, *MetrikaSegment,
+//*AppMetricaSegment, *CircleGeoSegment, *PolygonGeoSegment, *UploadingSegment or *UnknownSegment.
+func (c *Client) SegmentsList(pixel ...int) ([]Segment, error) {
+	return c.SegmentsListContext(context.Background(), pixel...)
+}
+
+//SegmentsListContext - SegmentsList with a context.
+func (c *Client) SegmentsListContext(ctx context.Context, pixel ...int) (segments []Segment, err error) {
+	ctx, op, err := c.begin(ctx, "SegmentsList", 0)
+	if err != nil {
+		return nil, err
+	}
+	defer op.end(&err)
 	requestPath := "segments"
 	if len(pixel) > 0 {
 		requestPath += fmt.Sprintf("?pixel=%d", pixel[0])
 	}
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodGet,
-	}, requestPath)
-	if err != nil {
-		return nil, err
-	}
 	var response struct {
-		Segments []map[string]interface{} `json:"segments"`
+		Segments []json.RawMessage `json:"segments"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodGet, requestPath, nil, &response); err != nil {
 		return nil, err
 	}
-	if len(response.Errors) != 0 {
-		return nil, response.Error()
+	segments = make([]Segment, 0, len(response.Segments))
+	for _, raw := range response.Segments {
+		segments = append(segments, decodeSegment(raw))
 	}
-	//TODO: Apply a method to separate segments by type and break them into structures
-	//Problem: Problem: Different formats returns as one array. Bad fields-description in API documentation
-	return response.Segments, nil
+	return segments, nil
 }
 
 //CreateFileSegment - creates a segment from a data file. The file must have at least 1000 entries.
 func (c *Client) CreateFileSegment(segment *UploadingSegment, filename string) error {
-	var f *os.File
-	var err error
-	if f, err = os.Open(filename); err != nil {
+	return c.CreateFileSegmentContext(context.Background(), segment, filename)
+}
+
+//CreateFileSegmentContext - CreateFileSegment with a context.
+func (c *Client) CreateFileSegmentContext(ctx context.Context, segment *UploadingSegment, filename string) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateFileSegment", 0)
+	if err != nil {
 		return err
 	}
-	defer closer(f)
-	return c.CreateReaderSegment(segment, f, false)
+	defer op.end(&err)
+	return c.createFileSegment(ctx, op, segment, filename, false)
 }
 
 //CreateCSVSegment - creates a segment from a csv data file. The file must have at least 1000 entries.
 func (c *Client) CreateCSVSegment(segment *UploadingSegment, filename string) error {
+	return c.CreateCSVSegmentContext(context.Background(), segment, filename)
+}
+
+//CreateCSVSegmentContext - CreateCSVSegment with a context.
+func (c *Client) CreateCSVSegmentContext(ctx context.Context, segment *UploadingSegment, filename string) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateCSVSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createFileSegment(ctx, op, segment, filename, true)
+}
+
+func (c *Client) createFileSegment(ctx context.Context, op *Operation, segment *UploadingSegment, filename string, isCSV bool) error {
 	var f *os.File
 	var err error
 	if f, err = os.Open(filename); err != nil {
 		return err
 	}
-	defer closer(f)
-	return c.CreateReaderSegment(segment, f, true)
+	defer c.closer(f)
+	return c.createReaderSegment(ctx, op, segment, f, isCSV)
 }
 
 //CreateReaderSegment - creates a segment from a reader. The reader must have at least 1000 entries.
 func (c *Client) CreateReaderSegment(segment *UploadingSegment, reader io.Reader, isCSV bool) error {
-	rp, wp := io.Pipe()
-	mpw := multipart.NewWriter(wp)
-	errorChan := make(chan error, 1)
-	go func() {
-		var part io.Writer
-		var err error
-		defer closer(wp)
-		if part, err = mpw.CreateFormFile("file", segment.Name); err != nil {
-			errorChan <- err
-			return
-		}
-		if _, err = io.Copy(part, reader); err != nil {
-			errorChan <- err
-		}
-		if err = mpw.Close(); err != nil {
-			errorChan <- err
-		}
-		errorChan <- nil
-	}()
-	URLPath := "upload_file"
-	if isCSV {
-		URLPath = "upload_csv_file"
-	}
-	req := http.Request{
-		Method: http.MethodPost,
-		Header: http.Header{
-			"Content-Type": {mpw.FormDataContentType()},
-		},
-		Body: rp,
-	}
-	resp, err := c.Do(&req, fmt.Sprintf("segments/%s", URLPath))
+	return c.CreateReaderSegmentContext(context.Background(), segment, reader, isCSV)
+}
+
+//CreateReaderSegmentContext - CreateReaderSegment with a context.
+//Cancelling the context aborts the upload and stops the goroutine copying the reader
+//(as soon as the current Read call returns).
+func (c *Client) CreateReaderSegmentContext(ctx context.Context, segment *UploadingSegment, reader io.Reader, isCSV bool) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateReaderSegment", 0)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
-	if err := <-errorChan; err != nil {
-		return err
+	defer op.end(&err)
+	return c.createReaderSegment(ctx, op, segment, reader, isCSV)
+}
+
+func (c *Client) createReaderSegment(ctx context.Context, op *Operation, segment *UploadingSegment, reader io.Reader, isCSV bool) error {
+	op.segmentType = SegmentTypeUploading
+	URLPath := "upload_file"
+	if isCSV {
+		URLPath = "upload_csv_file"
 	}
 	requestStruct := struct {
 		Segment *UploadingSegment `json:"segment"`
 		APIError
 	}{Segment: segment}
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
+	file := &MultipartFile{Filename: segment.Name, Reader: reader}
+	if err := c.upload(ctx, op, http.MethodPost, fmt.Sprintf("segments/%s", URLPath), file, &requestStruct); err != nil {
 		return err
 	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
-	}
 	if segment.ID == 0 {
 		return ErrNotCreated
 	}
+	op.setSegment(segment)
 	return nil
 }
 
-//SaveUploadedSegment - saves a segment created from a data file.
-func (c *Client) SaveUploadedSegment(segment *UploadingSegment) error {
+//ModifySegmentData - changes data of the uploaded segment keeping its ID: adds (ModificationAddition)
+//or removes (ModificationSubtraction) the identifiers from the reader, or replaces (ModificationReplace)
+//the segment data with them. The reader is streamed like in CreateReaderSegment.
+func (c *Client) ModifySegmentData(segment *UploadingSegment, reader io.Reader, modificationType string) error {
+	return c.ModifySegmentDataContext(context.Background(), segment, reader, modificationType)
+}
+
+//ModifySegmentDataContext - ModifySegmentData with a context.
+func (c *Client) ModifySegmentDataContext(ctx context.Context, segment *UploadingSegment, reader io.Reader, modificationType string) (err error) {
+	if segment == nil || segment.ID == 0 {
+		return fmt.Errorf("%w: segment with ID is required", ErrInvalidParameter)
+	}
+	if reader == nil {
+		return fmt.Errorf("%w: reader is required", ErrInvalidParameter)
+	}
+	switch modificationType {
+	case ModificationAddition, ModificationSubtraction, ModificationReplace:
+	default:
+		return fmt.Errorf("%w: unknown modification type %q", ErrInvalidParameter, modificationType)
+	}
+	ctx, op, err := c.begin(ctx, "ModifySegmentData", segment.ID)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	op.setSegment(segment)
 	requestStruct := struct {
 		Segment *UploadingSegment `json:"segment"`
 		APIError
 	}{Segment: segment}
-	jsonBody, err := json.Marshal(requestStruct)
+	file := &MultipartFile{Filename: segment.Name, Reader: reader}
+	path := fmt.Sprintf("segment/%d/modify_data?modification_type=%s", segment.ID, modificationType)
+	return c.upload(ctx, op, http.MethodPost, path, file, &requestStruct)
+}
+
+//SaveUploadedSegment - saves a segment created from a data file.
+func (c *Client) SaveUploadedSegment(segment *UploadingSegment) error {
+	return c.SaveUploadedSegmentContext(context.Background(), segment)
+}
+
+//SaveUploadedSegmentContext - SaveUploadedSegment with a context.
+func (c *Client) SaveUploadedSegmentContext(ctx context.Context, segment *UploadingSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "SaveUploadedSegment", segment.ID)
 	if err != nil {
 		return err
 	}
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPost,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, fmt.Sprintf("segment/%d/confirm?", segment.ID))
+	defer op.end(&err)
+	op.setSegment(segment)
+	requestStruct := struct {
+		Segment *UploadingSegment `json:"segment"`
+		APIError
+	}{Segment: segment}
+	return c.call(ctx, http.MethodPost, fmt.Sprintf("segment/%d/confirm?", segment.ID), &requestStruct, &requestStruct)
+}
+
+//SaveClientIDSegment - saves a segment of ClientIDs uploaded from a data file (ContentType is ClientID).
+//CounterID must be the Yandex.Metrica counter the ClientIDs were collected by.
+func (c *Client) SaveClientIDSegment(segment *ClientIDSegment) error {
+	return c.SaveClientIDSegmentContext(context.Background(), segment)
+}
+
+//SaveClientIDSegmentContext - SaveClientIDSegment with a context.
+func (c *Client) SaveClientIDSegmentContext(ctx context.Context, segment *ClientIDSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "SaveClientIDSegment", segment.ID)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
+	defer op.end(&err)
+	op.setSegment(segment)
+	if segment.CounterID <= 0 {
+		return fmt.Errorf("%w: counter ID %d must be positive", ErrInvalidParameter, segment.CounterID)
 	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
+	if segment.ContentType == "" {
+		segment.ContentType = ClientID
 	}
-	return nil
+	if segment.ContentType != ClientID {
+		return fmt.Errorf("%w: content type %q of ClientID segment must be %q", ErrInvalidParameter, segment.ContentType, ClientID)
+	}
+	requestStruct := struct {
+		Segment *ClientIDSegment `json:"segment"`
+		APIError
+	}{Segment: segment}
+	return c.call(ctx, http.MethodPost, fmt.Sprintf("segment/%d/confirm_client_id", segment.ID), &requestStruct, &requestStruct)
 }
 
 //RemoveSegment - deletes the specified segment.
 func (c *Client) RemoveSegment(id int64) error {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodDelete,
-	}, fmt.Sprintf("segment/%d", id))
+	return c.RemoveSegmentContext(context.Background(), id)
+}
+
+//RemoveSegmentContext - RemoveSegment with a context.
+func (c *Client) RemoveSegmentContext(ctx context.Context, id int64) (err error) {
+	ctx, op, err := c.begin(ctx, "RemoveSegment", id)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
 	var respStruct struct {
 		Success bool `json:"success"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&respStruct); err != nil {
+	if err := c.call(ctx, http.MethodDelete, fmt.Sprintf("segment/%d", id), nil, &respStruct); err != nil {
 		return err
 	}
-	if len(respStruct.Errors) != 0 {
-		return respStruct.Error()
-	}
 	if !respStruct.Success {
 		return ErrNotDeleted
 	}
@@ -268,109 +353,259 @@ func (c *Client) RemoveSegment(id int64) error {
 //If different conditions are used when creating a segment (for example, several labels are specified),
 //then a user who satisfies all the specified conditions at the same time will get into the segment.
 func (c *Client) CreatePixelSegment(segment *PixelSegment) error {
-	return c.createSegment(segment, "create_pixel")
+	return c.CreatePixelSegmentContext(context.Background(), segment)
+}
+
+//CreatePixelSegmentContext - CreatePixelSegment with a context.
+func (c *Client) CreatePixelSegmentContext(ctx context.Context, segment *PixelSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "CreatePixelSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createSegment(ctx, segment, "create_pixel")
 }
 
 //CreateLookalikeSegment - creates a “lookalike” type segment with the specified parameters.
 func (c *Client) CreateLookalikeSegment(segment *LookalikeSegment) error {
-	return c.createSegment(segment, "create_lookalike")
+	return c.CreateLookalikeSegmentContext(context.Background(), segment)
+}
+
+//CreateLookalikeSegmentContext - CreateLookalikeSegment with a context.
+func (c *Client) CreateLookalikeSegmentContext(ctx context.Context, segment *LookalikeSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateLookalikeSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createSegment(ctx, segment, "create_lookalike")
 }
 
 //CreateMetrikaSegment - creates a segment from a metric with the specified parameters.
 func (c *Client) CreateMetrikaSegment(segment *MetrikaSegment) error {
-	return c.createSegment(segment, "create_metrika")
+	return c.CreateMetrikaSegmentContext(context.Background(), segment)
+}
+
+//CreateMetrikaSegmentContext - CreateMetrikaSegment with a context.
+func (c *Client) CreateMetrikaSegmentContext(ctx context.Context, segment *MetrikaSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateMetrikaSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createSegment(ctx, segment, "create_metrika")
 }
 
 //CreateAppMetrikaSegment - creates a segment from AppMetrica with the specified parameters.
 func (c *Client) CreateAppMetrikaSegment(segment *AppMetricaSegment) error {
-	return c.createSegment(segment, "create_appmetrica")
+	return c.CreateAppMetrikaSegmentContext(context.Background(), segment)
+}
+
+//CreateAppMetrikaSegmentContext - CreateAppMetrikaSegment with a context.
+func (c *Client) CreateAppMetrikaSegmentContext(ctx context.Context, segment *AppMetricaSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateAppMetrikaSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createSegment(ctx, segment, "create_appmetrica")
 }
 
 //CreateCircleGeoSegment - creates a segment based on geolocation data with the “circle” type.
 func (c *Client) CreateCircleGeoSegment(segment *CircleGeoSegment) error {
-	return c.createSegment(segment, "create_geo")
+	return c.CreateCircleGeoSegmentContext(context.Background(), segment)
+}
+
+//CreateCircleGeoSegmentContext - CreateCircleGeoSegment with a context.
+func (c *Client) CreateCircleGeoSegmentContext(ctx context.Context, segment *CircleGeoSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "CreateCircleGeoSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createSegment(ctx, segment, "create_geo")
 }
 
 //CreatePolygonGeoSegment - creates a segment based on geolocation data with the “polygons” type.
 func (c *Client) CreatePolygonGeoSegment(segment *PolygonGeoSegment) error {
-	return c.createSegment(segment, "create_geo_polygon")
+	return c.CreatePolygonGeoSegmentContext(context.Background(), segment)
 }
 
-func (c *Client) createSegment(segment interface{}, URLPath string) error {
+//CreatePolygonGeoSegmentContext - CreatePolygonGeoSegment with a context.
+func (c *Client) CreatePolygonGeoSegmentContext(ctx context.Context, segment *PolygonGeoSegment) (err error) {
+	ctx, op, err := c.begin(ctx, "CreatePolygonGeoSegment", 0)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	return c.createSegment(ctx, segment, "create_geo_polygon")
+}
+
+func (c *Client) createSegment(ctx context.Context, segment Segment, URLPath string) error {
 	requestStruct := struct {
 		Segment interface{} `json:"segment"`
 		APIError
 	}{Segment: segment}
-	jsonBody, err := json.Marshal(requestStruct)
-	if err != nil {
-		return err
+	op, ok := OperationFromContext(ctx)
+	if ok {
+		op.segmentType = segmentType(segment)
 	}
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPost,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, "segments/"+URLPath)
-	if err != nil {
+	if err := c.call(ctx, http.MethodPost, "segments/"+URLPath, &requestStruct, &requestStruct); err != nil {
 		return err
 	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
-	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
+	if ok {
+		op.setSegment(segment)
 	}
 	return nil
 }
 
 //UpdateSegment - changes the specified segment.
 func (c *Client) UpdateSegment(ID int64, segment interface{}) error {
+	return c.UpdateSegmentContext(context.Background(), ID, segment)
+}
+
+//UpdateSegmentContext - UpdateSegment with a context.
+func (c *Client) UpdateSegmentContext(ctx context.Context, ID int64, segment interface{}) (err error) {
+	ctx, op, err := c.begin(ctx, "UpdateSegment", ID)
+	if err != nil {
+		return err
+	}
+	defer op.end(&err)
+	op.segmentType = segmentType(segment)
 	requestStruct := struct {
 		Segment interface{} `json:"segment"`
 		APIError
 	}{Segment: segment}
-	jsonBody, err := json.Marshal(requestStruct)
-	if err != nil {
-		return err
-	}
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPut,
-		Body:   ioutil.NopCloser(bytes.NewBuffer(jsonBody)),
-	}, fmt.Sprintf("segment/%d", ID))
-	if err != nil {
-		return err
-	}
-	defer closer(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(&requestStruct); err != nil {
-		return err
-	}
-	if len(requestStruct.Errors) != 0 {
-		return requestStruct.Error()
-	}
-	return nil
+	return c.call(ctx, http.MethodPut, fmt.Sprintf("segment/%d", ID), &requestStruct, &requestStruct)
 }
 
 //ReprocessSegment - starts a forced recount of a segment.
 //Quotas for using the method: 2 requests per segment and 20 requests for user_login in the last 24 hours.
+//Use WithQuotaTracker option to check quotas before calling API.
 func (c *Client) ReprocessSegment(segmentID int64) error {
-	resp, err := c.Do(&http.Request{
-		Method: http.MethodPut,
-	}, fmt.Sprintf("segment/%d/reprocess", segmentID))
+	return c.ReprocessSegmentContext(context.Background(), segmentID)
+}
+
+//ReprocessSegmentContext - ReprocessSegment with a context.
+func (c *Client) ReprocessSegmentContext(ctx context.Context, segmentID int64) (err error) {
+	ctx, op, err := c.begin(ctx, "ReprocessSegment", segmentID)
 	if err != nil {
 		return err
 	}
-	defer closer(resp.Body)
+	defer op.end(&err)
+	var sent int32
+	if c.quota != nil {
+		var release func() error
+		if release, err = c.quota.reserve(ctx, OperationReprocessSegment, segmentID, c.ulogin); err != nil {
+			return err
+		}
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			WroteHeaders: func() { atomic.StoreInt32(&sent, 1) },
+		})
+		defer func() {
+			//the call isn't counted by API if the request hasn't been sent
+			if err != nil && op.statusCode == 0 && atomic.LoadInt32(&sent) == 0 {
+				if releaseErr := release(); releaseErr != nil {
+					c.logger.Log(ctx, LevelWarn, "audience quota isn't released", append(op.fields(), "error", releaseErr.Error())...)
+				}
+			}
+		}()
+	}
 	var response struct {
 		Success bool `json:"success"`
 		APIError
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.call(ctx, http.MethodPut, fmt.Sprintf("segment/%d/reprocess", segmentID), nil, &response); err != nil {
 		return err
 	}
-	if len(response.Errors) != 0 {
-		return response.Error()
-	}
 	if !response.Success {
 		return ErrNotReprocessed
 	}
 	return nil
 }
+
+//decodeSegment - works out the concrete type of the segment from its fields and decodes it.
+//The "type" field decides when API returns it (geo segments are told apart by geo_segment_type or polygons),
+//type-specific fields are checked only when it's missing. Unknown types are decoded into *UnknownSegment.
+//A segment which can't be decoded into its type (a field has changed its type and so on) is returned
+//as *UnknownSegment with the base fields which could be decoded.
+func decodeSegment(raw json.RawMessage) Segment {
+	unknown := &UnknownSegment{Raw: append(json.RawMessage(nil), raw...)}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return unknown
+	}
+	segment := newSegment(fields)
+	if err := json.Unmarshal(raw, segment); err == nil {
+		if decoded, ok := segment.(*UnknownSegment); ok {
+			decoded.Raw = unknown.Raw
+		}
+		return segment
+	}
+	base := map[string]interface{}{
+		"id":          &unknown.ID,
+		"name":        &unknown.Name,
+		"status":      &unknown.Status,
+		"create_time": &unknown.CreateTime,
+		"owner":       &unknown.Owner,
+	}
+	for key, field := range base {
+		if value, ok := fields[key]; ok {
+			_ = json.Unmarshal(value, field)
+		}
+	}
+	return unknown
+}
+
+func newSegment(fields map[string]json.RawMessage) Segment {
+	has := func(key string) bool {
+		_, ok := fields[key]
+		return ok
+	}
+	var segmentType, geoSegmentType string
+	if raw, ok := fields["type"]; ok {
+		_ = json.Unmarshal(raw, &segmentType)
+	}
+	if raw, ok := fields["geo_segment_type"]; ok {
+		_ = json.Unmarshal(raw, &geoSegmentType)
+	}
+	isPolygon := geoSegmentType == "polygon" || has("polygons")
+	switch segmentType {
+	case "pixel":
+		return &PixelSegment{}
+	case "lookalike":
+		return &LookalikeSegment{}
+	case "metrika":
+		return &MetrikaSegment{}
+	case "appmetrica":
+		return &AppMetricaSegment{}
+	case "geo":
+		if isPolygon {
+			return &PolygonGeoSegment{}
+		}
+		return &CircleGeoSegment{}
+	case "uploading":
+		return &UploadingSegment{}
+	case "":
+		//old responses have no type, it's worked out from the fields
+	default:
+		return &UnknownSegment{}
+	}
+	switch {
+	case has("pixel_id"):
+		return &PixelSegment{}
+	case has("lookalike_link"):
+		return &LookalikeSegment{}
+	case has("metrika_segment_type"):
+		return &MetrikaSegment{}
+	case has("app_metrica_segment_type"):
+		return &AppMetricaSegment{}
+	case isPolygon:
+		return &PolygonGeoSegment{}
+	case has("geo_segment_type"):
+		return &CircleGeoSegment{}
+	case has("content_type") || has("hashed"):
+		return &UploadingSegment{}
+	}
+	return &UnknownSegment{}
+}
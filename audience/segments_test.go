@@ -4,14 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	. "github.com/smartystreets/goconvey/convey"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -143,10 +148,61 @@ func TestClient_SegmentsList(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			marshaled, _ := json.Marshal(data)
-			var dataMap []map[string]interface{}
-			_ = json.Unmarshal(marshaled, &dataMap)
-			So(segments, ShouldResemble, dataMap)
+			So(len(segments), ShouldEqual, len(data))
+			for i, segment := range data {
+				So(reflect.ValueOf(segments[i]).Elem().Interface(), ShouldResemble, segment)
+			}
+		})
+		Convey("unknown segment type", func(c C) {
+			raw := `{"id":90,"name":"dmp segment","status":"processed","create_time":"2020-01-02T15:04:05Z","owner":"lva","type":"dmp","dmp_id":3}`
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"segments":[` + raw + `]}`))
+			}))
+			defer ts.Close()
+			client.hc = ts.Client()
+			client.apiURL = ts.URL
+			segments, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			So(len(segments), ShouldEqual, 1)
+			unknown, ok := segments[0].(*UnknownSegment)
+			So(ok, ShouldBeTrue)
+			So(unknown.ID, ShouldEqual, 90)
+			So(unknown.Base().Name, ShouldEqual, "dmp segment")
+			So(string(unknown.Raw), ShouldEqual, raw)
+		})
+		Convey("type field has priority", func(c C) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"segments":[{"id":1,"type":"uploading","content_type":"mac"},{"id":2,"type":"geo","polygons":[]},` +
+					`{"id":3,"type":"uploading","pixel_id":3},{"id":4,"type":"geo","geo_segment_type":"polygon"},` +
+					`{"id":5,"type":"geo","geo_segment_type":"regular"},{"id":6,"type":"metrika","lookalike_link":1},` +
+					`{"id":7,"type":"dmp","pixel_id":3},{"id":8,"geo_segment_type":"polygon"},` +
+					`{"id":9,"type":"pixel","name":"broken","pixel_id":"abc"},{"id":10,"type":"geo","status":"processed","create_time":"yesterday"}]}`))
+			}))
+			defer ts.Close()
+			client.hc = ts.Client()
+			client.apiURL = ts.URL
+			segments, err := client.SegmentsList()
+			So(err, ShouldBeNil)
+			So(len(segments), ShouldEqual, 10)
+			So(segments[0], ShouldHaveSameTypeAs, &UploadingSegment{})
+			So(segments[0].(*UploadingSegment).ContentType, ShouldEqual, Mac)
+			So(segments[1], ShouldHaveSameTypeAs, &PolygonGeoSegment{})
+			So(segments[2], ShouldHaveSameTypeAs, &UploadingSegment{})
+			So(segments[3], ShouldHaveSameTypeAs, &PolygonGeoSegment{})
+			So(segments[4], ShouldHaveSameTypeAs, &CircleGeoSegment{})
+			So(segments[5], ShouldHaveSameTypeAs, &MetrikaSegment{})
+			So(segments[6], ShouldHaveSameTypeAs, &UnknownSegment{})
+			So(segments[7], ShouldHaveSameTypeAs, &PolygonGeoSegment{})
+			broken, ok := segments[8].(*UnknownSegment)
+			So(ok, ShouldBeTrue)
+			So(broken.ID, ShouldEqual, 9)
+			So(broken.Name, ShouldEqual, "broken")
+			So(string(broken.Raw), ShouldContainSubstring, `"pixel_id":"abc"`)
+			broken, ok = segments[9].(*UnknownSegment)
+			So(ok, ShouldBeTrue)
+			So(broken.ID, ShouldEqual, 10)
+			So(broken.Status, ShouldEqual, SegmentStatusProcessed)
+			So(broken.CreateTime.IsZero(), ShouldBeTrue)
 		})
 		Convey("zero results", func(c C) {
 			var data = make([]*interface{}, 0)
@@ -183,7 +239,7 @@ func TestClient_SegmentsList(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			_, err := client.SegmentsList()
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("with pixel", func(c C) {
 			pixelID := 123
@@ -222,10 +278,8 @@ func TestClient_SegmentsList(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			marshaled, _ := json.Marshal(data)
-			var dataMap []map[string]interface{}
-			_ = json.Unmarshal(marshaled, &dataMap)
-			So(segments, ShouldResemble, dataMap)
+			So(len(segments), ShouldEqual, 1)
+			So(*segments[0].(*PixelSegment), ShouldResemble, data[0])
 		})
 	})
 }
@@ -287,7 +341,7 @@ func TestClient_CreateAppMetrikaSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateAppMetrikaSegment(&AppMetricaSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -359,7 +413,7 @@ func TestClient_CreateCircleGeoSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateCircleGeoSegment(&CircleGeoSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -473,7 +527,7 @@ func TestClient_CreateLookalikeSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateLookalikeSegment(&LookalikeSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -584,7 +638,7 @@ func TestClient_CreateMetrikaSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateMetrikaSegment(&MetrikaSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -654,7 +708,7 @@ func TestClient_CreatePixelSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreatePixelSegment(&PixelSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -736,7 +790,7 @@ func TestClient_CreatePolygonGeoSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateAppMetrikaSegment(&AppMetricaSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
@@ -785,6 +839,18 @@ func TestClient_CreateReaderSegment(t *testing.T) {
 			So(segment.ID, ShouldEqual, 12)
 			So(isServerInvoked, ShouldBeTrue)
 		})
+		Convey("server responds without reading the file", func() {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"segment":{"id":5}}`))
+			}))
+			defer ts.Close()
+			client.hc = ts.Client()
+			client.apiURL = ts.URL
+			segment := UploadingSegment{BaseSegment: BaseSegment{Name: "test segment"}}
+			err := client.CreateReaderSegment(&segment, bytes.NewReader(make([]byte, 32<<20)), false)
+			So(err, ShouldBeNil)
+			So(segment.ID, ShouldEqual, 5)
+		})
 	})
 }
 
@@ -833,7 +899,7 @@ func TestClient_RemoveSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.RemoveSegment(segmentID)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("api return false", func(c C) {
 			isServerInvoked := false
@@ -900,7 +966,7 @@ func TestClient_ReprocessSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.ReprocessSegment(segmentID)
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 		Convey("api return false", func(c C) {
 			isServerInvoked := false
@@ -984,7 +1050,84 @@ func TestClient_SaveUploadedSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.SaveUploadedSegment(&UploadingSegment{BaseSegment: BaseSegment{ID: segmentID}})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
+		})
+	})
+}
+
+func TestClient_ModifySegmentData(t *testing.T) {
+	Convey("modify segment data", t, func(c C) {
+		var query, uploaded string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.So(r.Method, ShouldEqual, http.MethodPost)
+			c.So(r.URL.Path, ShouldEqual, "/v1/management/segment/12/modify_data")
+			query = r.URL.Query().Get("modification_type")
+			file, _, err := r.FormFile("file")
+			c.So(err, ShouldBeNil)
+			data, _ := ioutil.ReadAll(file)
+			uploaded = string(data)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"segment": UploadingSegment{
+				BaseSegment: BaseSegment{ID: 12, Name: "crm", Status: SegmentStatusIsUpdated},
+				ContentType: Crm,
+			}})
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL))
+		So(err, ShouldBeNil)
+		segment := &UploadingSegment{BaseSegment: BaseSegment{ID: 12, Name: "crm", Status: SegmentStatusProcessed}, ContentType: Crm}
+		for _, modificationType := range []string{ModificationAddition, ModificationSubtraction, ModificationReplace} {
+			So(client.ModifySegmentData(segment, strings.NewReader("a@example.com\nb@example.com"), modificationType), ShouldBeNil)
+			So(query, ShouldEqual, modificationType)
+			So(uploaded, ShouldEqual, "a@example.com\nb@example.com")
+			So(segment.Status, ShouldEqual, SegmentStatusIsUpdated)
+		}
+		Convey("invalid arguments aren't sent", func() {
+			query = ""
+			err := client.ModifySegmentData(segment, strings.NewReader("a@example.com"), "append")
+			So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			err = client.ModifySegmentData(nil, strings.NewReader("a@example.com"), ModificationAddition)
+			So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			err = client.ModifySegmentData(&UploadingSegment{}, strings.NewReader("a@example.com"), ModificationAddition)
+			So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			err = client.ModifySegmentData(segment, nil, ModificationAddition)
+			So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			So(query, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestClient_SaveClientIDSegment(t *testing.T) {
+	Convey("save ClientID segment", t, func(c C) {
+		isServerInvoked := false
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			isServerInvoked = true
+			c.So(r.Method, ShouldEqual, http.MethodPost)
+			c.So(r.URL.Path, ShouldEqual, "/v1/management/segment/12/confirm_client_id")
+			var s struct {
+				Segment map[string]interface{} `json:"segment"`
+			}
+			c.So(json.NewDecoder(r.Body).Decode(&s), ShouldBeNil)
+			c.So(s.Segment["counter_id"], ShouldEqual, 44147844)
+			c.So(s.Segment["content_type"], ShouldEqual, ClientID)
+			s.Segment["status"] = SegmentStatusIsProcessed
+			_ = json.NewEncoder(w).Encode(s)
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL))
+		So(err, ShouldBeNil)
+		Convey("simple case", func() {
+			segment := &ClientIDSegment{UploadingSegment: UploadingSegment{BaseSegment: BaseSegment{ID: 12, Name: "visitors"}}, CounterID: 44147844}
+			So(client.SaveClientIDSegment(segment), ShouldBeNil)
+			So(isServerInvoked, ShouldBeTrue)
+			So(segment.ContentType, ShouldEqual, ClientID)
+			So(segment.Status, ShouldEqual, SegmentStatusIsProcessed)
+		})
+		Convey("invalid segment isn't sent", func() {
+			err := client.SaveClientIDSegment(&ClientIDSegment{UploadingSegment: UploadingSegment{BaseSegment: BaseSegment{ID: 12}}})
+			So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			err = client.SaveClientIDSegment(&ClientIDSegment{UploadingSegment: UploadingSegment{BaseSegment: BaseSegment{ID: 12}, ContentType: Mac}, CounterID: 1})
+			So(errors.Is(err, ErrInvalidParameter), ShouldBeTrue)
+			So(isServerInvoked, ShouldBeFalse)
 		})
 	})
 }
@@ -1043,7 +1186,83 @@ func TestClient_UpdateSegment(t *testing.T) {
 			client.hc = ts.Client()
 			client.apiURL = ts.URL
 			err := client.CreateAppMetrikaSegment(&AppMetricaSegment{})
-			So(err, ShouldResemble, data.Error())
+			So(err, ShouldBeError, data.Error())
 		})
 	})
 }
+
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+func TestClient_CreateReaderSegmentContext(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	client, err := NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	Convey("create reader segment with context", t, func() {
+		Convey("cancel in-flight upload", func() {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(ioutil.Discard, r.Body)
+			}))
+			defer ts.Close()
+			client.hc = ts.Client()
+			client.apiURL = ts.URL
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			done := make(chan error, 1)
+			go func() {
+				done <- client.CreateReaderSegmentContext(ctx, &UploadingSegment{
+					BaseSegment: BaseSegment{Name: "endless segment"},
+				}, endlessReader{}, false)
+			}()
+			select {
+			case err := <-done:
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			case <-time.After(5 * time.Second):
+				t.Fatal("upload wasn't cancelled")
+			}
+		})
+		Convey("reader error aborts upload", func() {
+			readErr := errors.New("broken reader")
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(ioutil.Discard, r.Body)
+				_ = json.NewEncoder(w).Encode(struct {
+					Segment UploadingSegment `json:"segment"`
+				}{UploadingSegment{BaseSegment: BaseSegment{ID: 12}}})
+			}))
+			defer ts.Close()
+			client.hc = ts.Client()
+			client.apiURL = ts.URL
+			err := client.CreateReaderSegmentContext(context.Background(), &UploadingSegment{},
+				io.MultiReader(bytes.NewBufferString("aa:bb:cc:dd:ee:ff\n"), iotest.ErrReader(readErr)), false)
+			So(errors.Is(err, readErr), ShouldBeTrue)
+		})
+	})
+}
+
+func TestClient_SegmentsListContext(t *testing.T) {
+	_ = os.Setenv(tokenVariable, "blah")
+	client, err := NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	Convey("segments list with context", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer ts.Close()
+		client.hc = ts.Client()
+		client.apiURL = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.SegmentsListContext(ctx)
+		So(errors.Is(err, context.Canceled), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,159 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+//Segment statuses (BaseSegment.Status)
+const (
+	//SegmentStatusUploaded - the file is uploaded, but the segment isn't saved yet (see SaveUploadedSegment)
+	SegmentStatusUploaded = "uploaded"
+	//SegmentStatusConfirmed - the uploaded segment is saved and waits for processing
+	SegmentStatusConfirmed = "confirmed"
+	//SegmentStatusIsProcessed - the segment is being processed
+	SegmentStatusIsProcessed = "is_processed"
+	//SegmentStatusIsUpdated - the segment is being updated (after ReprocessSegment and so on)
+	SegmentStatusIsUpdated = "is_updated"
+	//SegmentStatusProcessed - the segment is ready
+	SegmentStatusProcessed = "processed"
+	//SegmentStatusProcessingFailed - processing of the segment is failed
+	SegmentStatusProcessingFailed = "processing_failed"
+	//SegmentStatusFewData - the segment is processed, but there is not enough data to use it
+	SegmentStatusFewData = "few_data"
+)
+
+//IsTerminalSegmentStatus - reports whether the segment with the status won't change without user actions
+func IsTerminalSegmentStatus(status string) bool {
+	switch status {
+	case SegmentStatusProcessed, SegmentStatusProcessingFailed, SegmentStatusFewData:
+		return true
+	}
+	return false
+}
+
+//Errors of WaitForSegment
+var (
+	ErrSegmentNotFound = errors.New("segment not found")
+	//ErrSegmentFailed - matches SegmentStatusError of processing_failed and few_data statuses
+	ErrSegmentFailed = errors.New("segment processing failed")
+)
+
+//SegmentStatusError - the segment has got a terminal status other than processed
+type SegmentStatusError struct {
+	Segment Segment
+}
+
+func (e *SegmentStatusError) Error() string {
+	base := e.Segment.Base()
+	return fmt.Sprintf("segment %d (%s) has status %s", base.ID, base.Name, base.Status)
+}
+
+//Is - the error is ErrSegmentFailed
+func (e *SegmentStatusError) Is(target error) bool {
+	return target == ErrSegmentFailed
+}
+
+//Default polling intervals of WaitForSegment
+const (
+	DefaultWaitMinInterval = 10 * time.Second
+	DefaultWaitMaxInterval = 5 * time.Minute
+)
+
+//WaitOptions - options of WaitForSegment, zero value means defaults
+type WaitOptions struct {
+	//MinInterval - delay before the second poll, it's doubled for every next poll (10 seconds by default)
+	MinInterval time.Duration
+	//MaxInterval - maximum delay between polls (5 minutes by default)
+	MaxInterval time.Duration
+	//Jitter - part of the delay which is randomized, from 0 to 1
+	Jitter float64
+	//Pixel - pixel of the segment to reduce the list polled (see SegmentsList)
+	Pixel int
+	//OnPoll - called with the segment after every poll
+	OnPoll func(segment Segment)
+}
+
+//WaitForSegment - polls SegmentsList until the segment gets a terminal status.
+//Returns the segment of concrete type if it's processed, *SegmentStatusError (ErrSegmentFailed)
+//if its status is processing_failed or few_data and ErrSegmentNotFound if it's deleted.
+//Temporary errors (5xx, quotas, network) don't stop polling, so use the context to limit the time.
+//Uploaded segments must be saved by SaveUploadedSegment before waiting.
+func (c *Client) WaitForSegment(ctx context.Context, id int64, opts WaitOptions) (Segment, error) {
+	policy := RetryPolicy{
+		MinBackoff: opts.MinInterval,
+		MaxBackoff: opts.MaxInterval,
+		Jitter:     opts.Jitter,
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultWaitMinInterval
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultWaitMaxInterval
+	}
+	var pixel []int
+	if opts.Pixel != 0 {
+		pixel = []int{opts.Pixel}
+	}
+	for attempt := 1; ; attempt++ {
+		segments, err := c.SegmentsListContext(ctx, pixel...)
+		switch {
+		case err == nil:
+			segment := findSegment(segments, id)
+			if segment == nil {
+				return nil, ErrSegmentNotFound
+			}
+			if opts.OnPoll != nil {
+				opts.OnPoll(segment)
+			}
+			status := segment.Base().Status
+			if status == SegmentStatusProcessed {
+				return segment, nil
+			}
+			if IsTerminalSegmentStatus(status) {
+				return segment, &SegmentStatusError{Segment: segment}
+			}
+		case !isTemporary(err):
+			return nil, err
+		}
+		timer := time.NewTimer(policy.backoff(attempt, nil))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func findSegment(segments []Segment, id int64) Segment {
+	for _, segment := range segments {
+		if segment.Base().ID == id {
+			return segment
+		}
+	}
+	return nil
+}
+
+//isTemporary - reports whether the error may disappear if the call is repeated later:
+//backend and quota errors, network timeouts and connections dropped by the server.
+//Refused connections, TLS and URL errors mean the client is misconfigured and aren't temporary.
+func isTemporary(err error) bool {
+	if errors.Is(err, ErrBackendError) || errors.Is(err, ErrQuotaExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	//request timeout (WithTimeout), the callers check their context themselves
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,118 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_WaitForSegment(t *testing.T) {
+	Convey("wait for segment", t, func() {
+		var polls int32
+		statuses := []string{SegmentStatusIsProcessed, "", SegmentStatusIsUpdated}
+		final := SegmentStatusProcessed
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			poll := int(atomic.AddInt32(&polls, 1)) - 1
+			status := final
+			if poll < len(statuses) {
+				status = statuses[poll]
+			}
+			if status == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"segments":[{"id":1,"type":"geo","status":"processed"},{"id":7,"type":"lookalike","name":"lal","status":%q}]}`, status)
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL))
+		So(err, ShouldBeNil)
+		opts := WaitOptions{MinInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+		Convey("processed", func() {
+			var seen []string
+			opts.OnPoll = func(segment Segment) {
+				seen = append(seen, segment.Base().Status)
+			}
+			segment, err := client.WaitForSegment(context.Background(), 7, opts)
+			So(err, ShouldBeNil)
+			So(segment, ShouldHaveSameTypeAs, &LookalikeSegment{})
+			So(segment.Base().Status, ShouldEqual, SegmentStatusProcessed)
+			So(seen, ShouldResemble, []string{SegmentStatusIsProcessed, SegmentStatusIsUpdated, SegmentStatusProcessed})
+		})
+		Convey("few data", func() {
+			final = SegmentStatusFewData
+			segment, err := client.WaitForSegment(context.Background(), 7, opts)
+			So(errors.Is(err, ErrSegmentFailed), ShouldBeTrue)
+			var statusErr *SegmentStatusError
+			So(errors.As(err, &statusErr), ShouldBeTrue)
+			So(statusErr.Segment.Base().Status, ShouldEqual, SegmentStatusFewData)
+			So(segment, ShouldNotBeNil)
+		})
+		Convey("deleted segment", func() {
+			_, err := client.WaitForSegment(context.Background(), 404, opts)
+			So(err, ShouldEqual, ErrSegmentNotFound)
+		})
+		Convey("context deadline", func() {
+			final = SegmentStatusIsProcessed
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err := client.WaitForSegment(ctx, 7, opts)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+	})
+}
+
+func TestClient_WaitForSegmentNetworkErrors(t *testing.T) {
+	Convey("network errors", t, func() {
+		opts := WaitOptions{MinInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Convey("misconfigured client fails fast", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			unreachable := "http://" + listener.Addr().String()
+			_ = listener.Close()
+			for _, baseURL := range []string{unreachable, "ftp://127.0.0.1"} {
+				client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(baseURL))
+				So(err, ShouldBeNil)
+				start := time.Now()
+				_, err = client.WaitForSegment(ctx, 7, opts)
+				So(err, ShouldNotBeNil)
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeFalse)
+				So(time.Since(start), ShouldBeLessThan, time.Second)
+			}
+		})
+		Convey("request timeout is temporary", func() {
+			var polls int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&polls, 1) == 1 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				_, _ = w.Write([]byte(`{"segments":[{"id":7,"type":"lookalike","status":"processed"}]}`))
+			}))
+			defer ts.Close()
+			client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL), WithTimeout(20*time.Millisecond))
+			So(err, ShouldBeNil)
+			segment, err := client.WaitForSegment(ctx, 7, opts)
+			So(err, ShouldBeNil)
+			So(segment.Base().Status, ShouldEqual, SegmentStatusProcessed)
+			So(atomic.LoadInt32(&polls), ShouldBeGreaterThan, 1)
+		})
+	})
+}
+
+func TestIsTerminalSegmentStatus(t *testing.T) {
+	Convey("terminal statuses", t, func() {
+		So(IsTerminalSegmentStatus(SegmentStatusProcessed), ShouldBeTrue)
+		So(IsTerminalSegmentStatus(SegmentStatusProcessingFailed), ShouldBeTrue)
+		So(IsTerminalSegmentStatus(SegmentStatusFewData), ShouldBeTrue)
+		So(IsTerminalSegmentStatus(SegmentStatusUploaded), ShouldBeFalse)
+		So(IsTerminalSegmentStatus(SegmentStatusIsProcessed), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,222 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//YandexOAuthURL - Yandex OAuth token endpoint
+const YandexOAuthURL = "https://oauth.yandex.ru/token"
+
+//TokenSource - returns OAuth token for every request
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+//TokenRefresher - a token source which can get a new token when API rejects the current one (401)
+type TokenRefresher interface {
+	TokenSource
+	Refresh(ctx context.Context) (string, error)
+}
+
+//StaticTokenSource - always returns the same token
+type StaticTokenSource string
+
+//Token - returns the token
+func (s StaticTokenSource) Token(context.Context) (string, error) {
+	if s == "" {
+		return "", ErrTokenIsNotSet
+	}
+	return string(s), nil
+}
+
+//FileTokenSource - reads the token from the file and re-reads it when the file is changed
+type FileTokenSource struct {
+	Path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+//NewFileTokenSource - creates a token source reading the file
+func NewFileTokenSource(path string) *FileTokenSource {
+	return &FileTokenSource{Path: path}
+}
+
+//Token - returns the token from the file (the file is read again only if its modification time was changed)
+func (s *FileTokenSource) Token(context.Context) (string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && info.ModTime().Equal(s.modTime) {
+		return s.token, nil
+	}
+	return s.read(info.ModTime())
+}
+
+//Refresh - reads the file again
+func (s *FileTokenSource) Refresh(context.Context) (string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(info.ModTime())
+}
+
+func (s *FileTokenSource) read(modTime time.Time) (string, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", ErrTokenIsNotSet
+	}
+	s.token, s.modTime = token, modTime
+	return token, nil
+}
+
+//OAuthError - error returned by OAuth server
+type OAuthError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("oauth: %d %s: %s", e.StatusCode, e.Code, e.Description)
+}
+
+//OAuthTokenSource - gets access tokens by refresh token (OAuth2 refresh_token grant).
+//The access token is cached until it expires.
+type OAuthTokenSource struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	//TokenURL - OAuth token endpoint (YandexOAuthURL if empty)
+	TokenURL string
+	//HTTPClient - client to call OAuth server (http.DefaultClient if nil)
+	HTTPClient *http.Client
+	//OnRefresh - called after every refresh, use it to persist rotated refresh token
+	OnRefresh func(accessToken, refreshToken string, expiry time.Time)
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+//expiryDelta - the token is refreshed a bit earlier than it expires
+const expiryDelta = time.Minute
+
+//Token - returns cached access token or gets a new one
+func (s *OAuthTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && (s.expiry.IsZero() || time.Now().Add(expiryDelta).Before(s.expiry)) {
+		return s.accessToken, nil
+	}
+	return s.refresh(ctx)
+}
+
+//Refresh - gets a new access token
+func (s *OAuthTokenSource) Refresh(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh(ctx)
+}
+
+func (s *OAuthTokenSource) refresh(ctx context.Context) (string, error) {
+	if s.RefreshToken == "" {
+		return "", errors.New("oauth: refresh token isn't set")
+	}
+	tokenURL := s.TokenURL
+	if tokenURL == "" {
+		tokenURL = YandexOAuthURL
+	}
+	hc := s.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.RefreshToken},
+		"client_id":     {s.ClientID},
+		"client_secret": {s.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := hc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := OAuthError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(body, &oauthErr)
+		return "", &oauthErr
+	}
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("oauth: server returned empty access token")
+	}
+	s.accessToken = token.AccessToken
+	s.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken != "" {
+		s.RefreshToken = token.RefreshToken
+	}
+	if s.OnRefresh != nil {
+		s.OnRefresh(s.accessToken, s.RefreshToken, s.expiry)
+	}
+	return s.accessToken, nil
+}
+
+//refreshAndResend - gets a new token after 401 response and sends the request once again
+func (c *Client) refreshAndResend(req *http.Request, resp *http.Response) (*http.Response, error) {
+	refresher, ok := c.tokens.(TokenRefresher)
+	if !ok || !canResend(req) {
+		return resp, nil
+	}
+	c.drain(resp)
+	token, err := refresher.Refresh(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("can't refresh token after 401 response: %w", err)
+	}
+	retry, err := cloneRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	retry.Header.Set("Authorization", fmt.Sprintf("OAuth %s", token))
+	return c.send(retry)
+}
@@ -0,0 +1,144 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenSource(t *testing.T) {
+	Convey("file token source", t, func() {
+		dir, err := ioutil.TempDir("", "token")
+		So(err, ShouldBeNil)
+		defer func() { _ = os.RemoveAll(dir) }()
+		path := filepath.Join(dir, "token")
+		So(ioutil.WriteFile(path, []byte("first\n"), 0600), ShouldBeNil)
+		source := NewFileTokenSource(path)
+		token, err := source.Token(context.Background())
+		So(err, ShouldBeNil)
+		So(token, ShouldEqual, "first")
+		Convey("file is re-read on change", func() {
+			So(ioutil.WriteFile(path, []byte("second"), 0600), ShouldBeNil)
+			So(os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)), ShouldBeNil)
+			token, err := source.Token(context.Background())
+			So(err, ShouldBeNil)
+			So(token, ShouldEqual, "second")
+		})
+		Convey("empty file", func() {
+			So(ioutil.WriteFile(path, []byte(" \n"), 0600), ShouldBeNil)
+			_, err := source.Refresh(context.Background())
+			So(err, ShouldEqual, ErrTokenIsNotSet)
+		})
+	})
+}
+
+func TestOAuthTokenSource(t *testing.T) {
+	Convey("oauth token source", t, func(c C) {
+		refreshes := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			refreshes++
+			c.So(r.Method, ShouldEqual, http.MethodPost)
+			c.So(r.FormValue("grant_type"), ShouldEqual, "refresh_token")
+			c.So(r.FormValue("client_id"), ShouldEqual, "id")
+			c.So(r.FormValue("client_secret"), ShouldEqual, "secret")
+			if r.FormValue("refresh_token") == "revoked" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"refresh token expired"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":  "access" + string(rune('0'+refreshes)),
+				"refresh_token": "rotated",
+				"expires_in":    3600,
+			})
+		}))
+		defer ts.Close()
+		var persisted string
+		source := &OAuthTokenSource{
+			ClientID:     "id",
+			ClientSecret: "secret",
+			RefreshToken: "initial",
+			TokenURL:     ts.URL,
+			OnRefresh: func(accessToken, refreshToken string, expiry time.Time) {
+				persisted = refreshToken
+			},
+		}
+		Convey("token is cached", func() {
+			token, err := source.Token(context.Background())
+			So(err, ShouldBeNil)
+			So(token, ShouldEqual, "access1")
+			token, err = source.Token(context.Background())
+			So(err, ShouldBeNil)
+			So(token, ShouldEqual, "access1")
+			So(refreshes, ShouldEqual, 1)
+			So(source.RefreshToken, ShouldEqual, "rotated")
+			So(persisted, ShouldEqual, "rotated")
+			token, err = source.Refresh(context.Background())
+			So(err, ShouldBeNil)
+			So(token, ShouldEqual, "access2")
+		})
+		Convey("oauth error", func() {
+			source.RefreshToken = "revoked"
+			_, err := source.Token(context.Background())
+			var oauthErr *OAuthError
+			So(errors.As(err, &oauthErr), ShouldBeTrue)
+			So(oauthErr.Code, ShouldEqual, "invalid_grant")
+			So(oauthErr.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+type countingRefresher struct {
+	tokens    []string
+	refreshes int
+}
+
+func (r *countingRefresher) Token(context.Context) (string, error) {
+	return r.tokens[r.refreshes], nil
+}
+
+func (r *countingRefresher) Refresh(context.Context) (string, error) {
+	r.refreshes++
+	return r.tokens[r.refreshes], nil
+}
+
+func TestClient_RefreshTokenOn401(t *testing.T) {
+	Convey("token refresh after 401", t, func(c C) {
+		source := &countingRefresher{tokens: []string{"old", "new", "newest"}}
+		var bodies []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if r.Header.Get("Authorization") != "OAuth new" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"errors":[{"error_type":"invalid_token","message":"expired"}],"code":401,"message":"expired"}`))
+				return
+			}
+			_, _ = w.Write(body)
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithTokenSource(source), WithBaseURL(ts.URL), WithHTTPClient(ts.Client()))
+		So(err, ShouldBeNil)
+		Convey("request is sent once again with new token", func() {
+			err := client.CreatePixel(&Pixel{Name: "pixel"})
+			So(err, ShouldBeNil)
+			So(source.refreshes, ShouldEqual, 1)
+			So(len(bodies), ShouldEqual, 2)
+			So(bodies[1], ShouldEqual, bodies[0])
+		})
+		Convey("token is refreshed only once", func() {
+			source.tokens = []string{"old", "still old", "new"}
+			_, err := client.PixelsList()
+			So(errors.Is(err, ErrAccessDenied), ShouldBeTrue)
+			So(source.refreshes, ShouldEqual, 1)
+		})
+	})
+}
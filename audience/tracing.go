@@ -0,0 +1,90 @@
+package audience
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//TracerName - name of the OpenTelemetry tracer the client creates spans with
+const TracerName = "github.com/nikon72ru/yandex-audience-api/audience"
+
+//Span attributes set by the client
+const (
+	AttributeSegmentID   = attribute.Key("audience.segment_id")
+	AttributeSegmentType = attribute.Key("audience.segment_type")
+	AttributeAccount     = attribute.Key("audience.account")
+	AttributeErrorTypes  = attribute.Key("audience.error_types")
+	AttributeStatusCode  = attribute.Key("http.status_code")
+)
+
+//Segment types reported in spans
+const (
+	SegmentTypePixel      = "pixel"
+	SegmentTypeLookalike  = "lookalike"
+	SegmentTypeMetrika    = "metrika"
+	SegmentTypeAppMetrica = "appmetrica"
+	SegmentTypeCircleGeo  = "circle_geo"
+	SegmentTypePolygonGeo = "polygon_geo"
+	SegmentTypeUploading  = "uploading"
+	SegmentTypeUnknown    = "unknown"
+)
+
+//segmentType - returns the type name of the segment for spans
+func segmentType(segment interface{}) string {
+	switch segment.(type) {
+	case *PixelSegment:
+		return SegmentTypePixel
+	case *LookalikeSegment:
+		return SegmentTypeLookalike
+	case *MetrikaSegment:
+		return SegmentTypeMetrika
+	case *AppMetricaSegment:
+		return SegmentTypeAppMetrica
+	case *CircleGeoSegment:
+		return SegmentTypeCircleGeo
+	case *PolygonGeoSegment:
+		return SegmentTypePolygonGeo
+	case *UploadingSegment, *ClientIDSegment:
+		return SegmentTypeUploading
+	case nil:
+		return ""
+	default:
+		return SegmentTypeUnknown
+	}
+}
+
+//endSpan - sets the result attributes of the operation and ends its span
+func (op *Operation) endSpan(err error) {
+	if op.SegmentID != 0 {
+		op.span.SetAttributes(AttributeSegmentID.Int64(op.SegmentID))
+	}
+	if op.segmentType != "" {
+		op.span.SetAttributes(AttributeSegmentType.String(op.segmentType))
+	}
+	if op.statusCode != 0 {
+		op.span.SetAttributes(AttributeStatusCode.Int(op.statusCode))
+	}
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && len(apiErr.Errors) > 0 {
+			op.span.SetAttributes(AttributeErrorTypes.StringSlice(apiErr.ErrorTypes()))
+		}
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+	op.span.End()
+}
+
+func (op *Operation) spanOptions() []trace.SpanStartOption {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if op.SegmentID != 0 {
+		attrs = append(attrs, AttributeSegmentID.Int64(op.SegmentID))
+	}
+	if op.Account != "" {
+		attrs = append(attrs, AttributeAccount.String(op.Account))
+	}
+	return []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...)}
+}
@@ -0,0 +1,96 @@
+package audience
+
+import (
+	"context"
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestClient_Tracing(t *testing.T) {
+	Convey("spans of client methods", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/management/segments/create_geo":
+				_ = json.NewEncoder(w).Encode(struct {
+					Segment CircleGeoSegment `json:"segment"`
+				}{CircleGeoSegment{BaseSegment: BaseSegment{ID: 15}}})
+			case "/v1/management/segments/upload_file":
+				_, _ = ioutil.ReadAll(r.Body)
+				_ = json.NewEncoder(w).Encode(struct {
+					Segment UploadingSegment `json:"segment"`
+				}{UploadingSegment{BaseSegment: BaseSegment{ID: 16}}})
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":[{"error_type":"invalid_parameter","message":"bad name","location":"name"}],"code":400,"message":"bad name"}`))
+			}
+		}))
+		defer ts.Close()
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL), WithTracerProvider(provider))
+		So(err, ShouldBeNil)
+		Convey("created segment", func() {
+			So(client.ForAccount("agency").CreateCircleGeoSegment(&CircleGeoSegment{BaseSegment: BaseSegment{Name: "geo"}}), ShouldBeNil)
+			spans := recorder.Ended()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name(), ShouldEqual, "audience.CreateCircleGeoSegment")
+			So(spans[0].SpanKind(), ShouldEqual, trace.SpanKindClient)
+			So(spans[0].Status().Code, ShouldEqual, codes.Unset)
+			attrs := spanAttributes(spans[0])
+			So(attrs[AttributeSegmentID].AsInt64(), ShouldEqual, 15)
+			So(attrs[AttributeSegmentType].AsString(), ShouldEqual, SegmentTypeCircleGeo)
+			So(attrs[AttributeStatusCode].AsInt64(), ShouldEqual, http.StatusOK)
+			So(attrs[AttributeAccount].AsString(), ShouldEqual, "agency")
+		})
+		Convey("uploaded segment", func() {
+			So(client.CreateReaderSegment(&UploadingSegment{BaseSegment: BaseSegment{Name: "upload"}}, strings.NewReader("a\nb"), false), ShouldBeNil)
+			spans := recorder.Ended()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name(), ShouldEqual, "audience.CreateReaderSegment")
+			attrs := spanAttributes(spans[0])
+			So(attrs[AttributeSegmentID].AsInt64(), ShouldEqual, 16)
+			So(attrs[AttributeSegmentType].AsString(), ShouldEqual, SegmentTypeUploading)
+		})
+		Convey("API error", func() {
+			So(client.RemoveSegment(42), ShouldNotBeNil)
+			spans := recorder.Ended()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name(), ShouldEqual, "audience.RemoveSegment")
+			So(spans[0].Status().Code, ShouldEqual, codes.Error)
+			So(spans[0].Events(), ShouldNotBeEmpty)
+			attrs := spanAttributes(spans[0])
+			So(attrs[AttributeSegmentID].AsInt64(), ShouldEqual, 42)
+			So(attrs[AttributeStatusCode].AsInt64(), ShouldEqual, http.StatusBadRequest)
+			So(attrs[AttributeErrorTypes].AsStringSlice(), ShouldResemble, []string{ErrorTypeInvalidParameter})
+		})
+		Convey("parent span from context", func() {
+			ctx, parent := provider.Tracer("test").Start(context.Background(), "refresh")
+			_, _ = client.PixelsListContext(ctx)
+			parent.End()
+			spans := recorder.Ended()
+			So(spans, ShouldHaveLength, 2)
+			So(spans[0].Name(), ShouldEqual, "audience.PixelsList")
+			So(spans[0].Parent().SpanID(), ShouldEqual, parent.SpanContext().SpanID())
+			So(spans[0].SpanContext().TraceID(), ShouldEqual, parent.SpanContext().TraceID())
+		})
+	})
+}
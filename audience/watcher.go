@@ -0,0 +1,187 @@
+package audience
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+//DefaultWatchInterval - default interval between polls of Watcher
+const DefaultWatchInterval = time.Minute
+
+//SegmentEvent - a change of segments found by Watcher:
+//*SegmentCreated, *SegmentStatusChanged, *SegmentRenamed or *SegmentDeleted. Use a type switch to handle it.
+type SegmentEvent interface {
+	segmentEvent()
+}
+
+//SegmentCreated - a new segment is found
+type SegmentCreated struct {
+	Segment Segment
+}
+
+//SegmentStatusChanged - status of the segment is changed
+type SegmentStatusChanged struct {
+	Segment   Segment
+	OldStatus string
+	NewStatus string
+}
+
+//SegmentRenamed - name of the segment is changed
+type SegmentRenamed struct {
+	Segment Segment
+	OldName string
+	NewName string
+}
+
+//SegmentDeleted - the segment isn't in the list anymore, Segment is its last known state
+type SegmentDeleted struct {
+	Segment Segment
+}
+
+func (*SegmentCreated) segmentEvent()       {}
+func (*SegmentStatusChanged) segmentEvent() {}
+func (*SegmentRenamed) segmentEvent()       {}
+func (*SegmentDeleted) segmentEvent()       {}
+
+//WatcherOptions - options of Watcher, zero value means defaults
+type WatcherOptions struct {
+	//Interval - interval between polls (1 minute by default)
+	Interval time.Duration
+	//Jitter - part of the interval which is randomized, from 0 to 1
+	Jitter float64
+	//Pixel - watch only segments of the pixel (see SegmentsList)
+	Pixel int
+	//EmitExisting - send SegmentCreated for the segments found by the first poll
+	EmitExisting bool
+	//Buffer - size of the events channel buffer
+	Buffer int
+	//OnError - called when a poll fails with a temporary error (backend, quota, network timeout), the watcher keeps polling
+	OnError func(err error)
+}
+
+//Watcher - polls SegmentsList and sends changes of segments to Events channel
+//	watcher := client.NewWatcher(audience.WatcherOptions{Interval: 5 * time.Minute})
+//	go watcher.Run(ctx)
+//	for event := range watcher.Events() {
+//		if changed, ok := event.(*audience.SegmentStatusChanged); ok && changed.NewStatus == audience.SegmentStatusFewData {
+//			alert(changed.Segment)
+//		}
+//	}
+type Watcher struct {
+	client   *Client
+	opts     WatcherOptions
+	events   chan SegmentEvent
+	snapshot map[int64]Segment
+}
+
+//NewWatcher - creates a watcher of the client's segments, call Run to start it
+func (c *Client) NewWatcher(opts WatcherOptions) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	return &Watcher{
+		client: c,
+		opts:   opts,
+		events: make(chan SegmentEvent, opts.Buffer),
+	}
+}
+
+//Events - returns the channel of events, it's closed when Run returns
+func (w *Watcher) Events() <-chan SegmentEvent {
+	return w.events
+}
+
+//Run - polls segments until the context is done or the poll fails with a non-temporary error
+//(ErrClientClosed, ErrAccessDenied, invalid token, refused connection and so on) and returns the error.
+//It must be called once.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+	var pixel []int
+	if w.opts.Pixel != 0 {
+		pixel = []int{w.opts.Pixel}
+	}
+	for {
+		segments, err := w.client.SegmentsListContext(ctx, pixel...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if !isTemporary(err) {
+				return err
+			}
+			if w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+		} else if err := w.emit(ctx, w.diff(segments)); err != nil {
+			return err
+		}
+		timer := time.NewTimer(w.interval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (w *Watcher) interval() time.Duration {
+	interval := w.opts.Interval
+	if w.opts.Jitter > 0 {
+		jitter := time.Duration(float64(interval) * w.opts.Jitter)
+		interval = interval - jitter + time.Duration(rand.Int63n(int64(2*jitter)+1))
+	}
+	return interval
+}
+
+//diff - returns changes since the previous poll and saves the new snapshot
+func (w *Watcher) diff(segments []Segment) []SegmentEvent {
+	snapshot := make(map[int64]Segment, len(segments))
+	for _, segment := range segments {
+		snapshot[segment.Base().ID] = segment
+	}
+	previous := w.snapshot
+	w.snapshot = snapshot
+	if previous == nil && !w.opts.EmitExisting {
+		return nil
+	}
+	var events []SegmentEvent
+	for _, segment := range segments {
+		old, ok := previous[segment.Base().ID]
+		if !ok {
+			events = append(events, &SegmentCreated{Segment: segment})
+			continue
+		}
+		oldBase, base := old.Base(), segment.Base()
+		if oldBase.Name != base.Name {
+			events = append(events, &SegmentRenamed{Segment: segment, OldName: oldBase.Name, NewName: base.Name})
+		}
+		if oldBase.Status != base.Status {
+			events = append(events, &SegmentStatusChanged{Segment: segment, OldStatus: oldBase.Status, NewStatus: base.Status})
+		}
+	}
+	deleted := make([]int64, 0)
+	for id := range previous {
+		if _, ok := snapshot[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i] < deleted[j] })
+	for _, id := range deleted {
+		events = append(events, &SegmentDeleted{Segment: previous[id]})
+	}
+	return events
+}
+
+func (w *Watcher) emit(ctx context.Context, events []SegmentEvent) error {
+	for _, event := range events {
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	Convey("watch segments", t, func() {
+		lists := []string{
+			`{"segments":[{"id":1,"type":"geo","name":"geo","status":"is_processed"},{"id":2,"type":"lookalike","name":"lal","status":"processed"}]}`,
+			"",
+			`{"segments":[{"id":1,"type":"geo","name":"moscow","status":"processed"},{"id":3,"type":"metrika","name":"goals","status":"is_processed"}]}`,
+		}
+		var polls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			poll := int(atomic.AddInt32(&polls, 1)) - 1
+			if poll >= len(lists) {
+				poll = len(lists) - 1
+			}
+			if lists[poll] == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(lists[poll]))
+		}))
+		defer ts.Close()
+		client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(ts.URL))
+		So(err, ShouldBeNil)
+		var pollErrors int32
+		opts := WatcherOptions{Interval: time.Millisecond, Jitter: 0.5, OnError: func(err error) {
+			atomic.AddInt32(&pollErrors, 1)
+		}}
+		Convey("changes", func() {
+			watcher := client.NewWatcher(opts)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error)
+			go func() {
+				done <- watcher.Run(ctx)
+			}()
+			var events []SegmentEvent
+			for event := range watcher.Events() {
+				events = append(events, event)
+				if len(events) == 4 {
+					cancel()
+				}
+			}
+			So(errors.Is(<-done, context.Canceled), ShouldBeTrue)
+			So(events, ShouldHaveLength, 4)
+			So(atomic.LoadInt32(&pollErrors), ShouldEqual, 1)
+
+			renamed, ok := events[0].(*SegmentRenamed)
+			So(ok, ShouldBeTrue)
+			So(renamed.Segment.Base().ID, ShouldEqual, 1)
+			So(renamed.OldName, ShouldEqual, "geo")
+			So(renamed.NewName, ShouldEqual, "moscow")
+
+			changed, ok := events[1].(*SegmentStatusChanged)
+			So(ok, ShouldBeTrue)
+			So(changed.Segment, ShouldHaveSameTypeAs, &CircleGeoSegment{})
+			So(changed.OldStatus, ShouldEqual, SegmentStatusIsProcessed)
+			So(changed.NewStatus, ShouldEqual, SegmentStatusProcessed)
+
+			created, ok := events[2].(*SegmentCreated)
+			So(ok, ShouldBeTrue)
+			So(created.Segment, ShouldHaveSameTypeAs, &MetrikaSegment{})
+
+			deleted, ok := events[3].(*SegmentDeleted)
+			So(ok, ShouldBeTrue)
+			So(deleted.Segment.Base().Name, ShouldEqual, "lal")
+		})
+		Convey("existing segments", func() {
+			opts.EmitExisting = true
+			opts.Buffer = 2
+			watcher := client.NewWatcher(opts)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = watcher.Run(ctx)
+			}()
+			first, second := <-watcher.Events(), <-watcher.Events()
+			So(first, ShouldHaveSameTypeAs, &SegmentCreated{})
+			So(second, ShouldHaveSameTypeAs, &SegmentCreated{})
+		})
+		Convey("closed client", func() {
+			watcher := client.NewWatcher(opts)
+			done := make(chan error)
+			go func() {
+				done <- watcher.Run(context.Background())
+			}()
+			<-watcher.Events()
+			So(client.Close(), ShouldBeNil)
+			for range watcher.Events() {
+			}
+			So(<-done, ShouldEqual, ErrClientClosed)
+			So(atomic.LoadInt32(&pollErrors), ShouldBeLessThanOrEqualTo, 1)
+		})
+		Convey("access denied", func() {
+			denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":[{"error_type":"access_denied","message":"Access denied"}],"code":403,"message":"Access denied"}`))
+			}))
+			defer denied.Close()
+			client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(denied.URL))
+			So(err, ShouldBeNil)
+			watcher := client.NewWatcher(opts)
+			So(errors.Is(watcher.Run(context.Background()), ErrAccessDenied), ShouldBeTrue)
+			_, ok := <-watcher.Events()
+			So(ok, ShouldBeFalse)
+			So(atomic.LoadInt32(&pollErrors), ShouldEqual, 0)
+		})
+		Convey("misconfigured client", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			unreachable := "http://" + listener.Addr().String()
+			_ = listener.Close()
+			for _, baseURL := range []string{unreachable, "ftp://127.0.0.1"} {
+				client, err := NewClient(context.Background(), WithToken("token"), WithBaseURL(baseURL))
+				So(err, ShouldBeNil)
+				watcher := client.NewWatcher(opts)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				err = watcher.Run(ctx)
+				cancel()
+				So(err, ShouldNotBeNil)
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeFalse)
+				_, ok := <-watcher.Events()
+				So(ok, ShouldBeFalse)
+			}
+			So(atomic.LoadInt32(&pollErrors), ShouldEqual, 0)
+		})
+		Convey("canceled before the first poll", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			watcher := client.NewWatcher(WatcherOptions{})
+			So(errors.Is(watcher.Run(ctx), context.Canceled), ShouldBeTrue)
+			_, ok := <-watcher.Events()
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -1,5 +1,11 @@
 module github.com/nikon72ru/yandex-audience-api
 
-go 1.13
+go 1.16
 
-require github.com/smartystreets/goconvey v1.6.4
+require (
+	github.com/prometheus/client_golang v1.11.1
+	github.com/smartystreets/goconvey v1.6.4
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+)